@@ -0,0 +1,293 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"telegramBot/internal/client"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxBatchSize is the most tracker links handled from one message
+const maxBatchSize = 20
+
+// pendingBatch is a set of tracker torrents from one message waiting for
+// the user to choose categories
+type pendingBatch struct {
+	Items   []pendingTorrent // Torrents not added yet
+	Total   int              // Number of torrents in the batch
+	Invalid []string         // Links that could not be parsed
+	Results []string         // One line per torrent already handled
+	PerItem bool             // Categories are chosen one torrent at a time
+}
+
+// messageLinks returns the http(s) links of a message, in order and without
+// duplicates. Links are taken from the text or caption and from text_link
+// entities, whose URL is hidden behind the text.
+func messageLinks(message *tgbotapi.Message) []string {
+	text, entities := message.Text, message.Entities
+	if text == "" {
+		text, entities = message.Caption, message.CaptionEntities
+	}
+
+	links := client.FindLinks(text)
+	for _, entity := range entities {
+		if entity.Type == "text_link" && entity.URL != "" {
+			links = append(links, entity.URL)
+		}
+	}
+
+	seen := make(map[string]bool, len(links))
+	unique := links[:0]
+	for _, link := range links {
+		if !seen[link] {
+			seen[link] = true
+			unique = append(unique, link)
+		}
+	}
+	return unique
+}
+
+// batchItemLabel names a torrent of a batch before its title is known
+func batchItemLabel(item pendingTorrent) string {
+	return fmt.Sprintf("%s #%s", item.Tracker, item.ID)
+}
+
+// handleTorrentLinks offers several tracker links from one message as a
+// batch, with one category for all of them or a category per torrent
+func (b *Bot) handleTorrentLinks(chatID int64, links []string) {
+	if !slices.Contains(b.config.AllowedUsers, chatID) {
+		return
+	}
+
+	batch := &pendingBatch{}
+	seen := make(map[string]bool)
+	for _, link := range links {
+		trackerName, id, err := b.trackerClient.ParseLink(link)
+		if err != nil {
+			batch.Invalid = append(batch.Invalid, fmt.Sprintf("%s: %v", link, err))
+			continue
+		}
+
+		item := pendingTorrent{Tracker: trackerName, ID: id}
+		if key := batchItemLabel(item); !seen[key] {
+			seen[key] = true
+			batch.Items = append(batch.Items, item)
+		}
+	}
+
+	if len(batch.Items) > maxBatchSize {
+		batch.Invalid = append(batch.Invalid, fmt.Sprintf("%d more links: at most %d torrents are added at once", len(batch.Items)-maxBatchSize, maxBatchSize))
+		batch.Items = batch.Items[:maxBatchSize]
+	}
+	batch.Total = len(batch.Items)
+
+	if batch.Total == 0 {
+		b.sendErrorMessage(chatID, "Error processing links:\n"+strings.Join(batch.Invalid, "\n"))
+		return
+	}
+
+	b.setPendingBatch(chatID, batch)
+
+	msg := tgbotapi.NewMessage(chatID, formatBatchPrompt(batch))
+	msg.ReplyMarkup = CreateBatchKeyboard(b.config.TorrentCategories, false)
+	if _, err := b.api.Send(msg); err != nil {
+		log.Printf("Error sending batch keyboard: %v", err)
+		b.sendErrorMessage(chatID, "Failed to send keyboard")
+	}
+}
+
+// formatBatchPrompt lists the torrents of a batch followed by the category question
+func formatBatchPrompt(batch *pendingBatch) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📦 %d torrents found:\n", batch.Total))
+	for i, item := range batch.Items {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, batchItemLabel(item)))
+	}
+
+	if len(batch.Invalid) > 0 {
+		sb.WriteString("\n⚠️ Skipped:\n")
+		for _, line := range batch.Invalid {
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	sb.WriteString("\nWhat category should these downloads be saved as? Choose one for all, or pick per torrent.")
+	return sb.String()
+}
+
+// formatBatchItemPrompt asks for the category of the next torrent of a batch
+func formatBatchItemPrompt(batch *pendingBatch) string {
+	position := batch.Total - len(batch.Items) + 1
+	return fmt.Sprintf("📦 Torrent %d of %d: %s\n\n%s", position, batch.Total, batchItemLabel(batch.Items[0]), categoryPrompt)
+}
+
+// formatBatchSummary reports the outcome of every torrent of a batch
+func formatBatchSummary(batch *pendingBatch) string {
+	added := 0
+	for _, line := range batch.Results {
+		if strings.HasPrefix(line, "✅") {
+			added++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📦 Added %d of %d torrents\n\n", added, batch.Total))
+	for _, line := range batch.Results {
+		sb.WriteString(line + "\n")
+	}
+	for _, line := range batch.Invalid {
+		sb.WriteString("⚠️ " + line + "\n")
+	}
+	return sb.String()
+}
+
+// handleBatchCallback switches a batch to per-torrent categories or skips
+// its current torrent
+func (b *Bot) handleBatchCallback(message *tgbotapi.Message, action string) {
+	chatID := message.Chat.ID
+	if !slices.Contains(b.config.AllowedUsers, chatID) {
+		return
+	}
+
+	switch action {
+	case "peritem":
+		b.mu.Lock()
+		batch, ok := b.pendingBatches[chatID]
+		if ok && len(batch.Items) > 0 {
+			batch.PerItem = true
+		}
+		b.mu.Unlock()
+		if !ok {
+			b.sendErrorMessage(chatID, "No pending torrents")
+			return
+		}
+		b.showBatch(message, batch)
+	case "skip":
+		b.advanceBatch(message, func(item pendingTorrent) string {
+			return "⏭ " + batchItemLabel(item) + ": skipped"
+		})
+	default:
+		b.sendErrorMessage(chatID, "Unknown action")
+	}
+}
+
+// handleBatchDownload adds the torrents of a batch to the chosen category:
+// all of them, or only the current one when categories are chosen per torrent
+func (b *Bot) handleBatchDownload(message *tgbotapi.Message, categoryKey string) {
+	chatID := message.Chat.ID
+	if !slices.Contains(b.config.AllowedUsers, chatID) {
+		return
+	}
+
+	category, exists := b.config.TorrentCategories[categoryKey]
+	if !exists {
+		b.sendErrorMessage(chatID, "Invalid category selected")
+		return
+	}
+
+	b.editMessageText(message, "Processing download request...")
+
+	// A captcha is requested once, even if it blocks several torrents
+	captchaRequested := false
+	b.advanceBatch(message, func(item pendingTorrent) string {
+		torrent, result, err := DownloadAndAddTorrent(b.trackerClient, b.qbtClient, b.checkFreeSpace, item.Tracker, item.ID, category.SavePath)
+		if err != nil {
+			if !captchaRequested {
+				captchaRequested = b.requestCaptcha(chatID, err)
+			}
+			return fmt.Sprintf("❌ %s: %s", batchItemLabel(item), describeError("downloading torrent", err))
+		}
+
+		// Remember who added the torrent to notify them when it completes
+		b.trackTorrentOwner(torrent.Hash, chatID)
+		return fmt.Sprintf("✅ %s → %s", result, category.SavePath)
+	})
+}
+
+// advanceBatch takes the torrents due for the current choice out of the
+// batch, handles each with add, and then shows the next torrent or the summary
+func (b *Bot) advanceBatch(message *tgbotapi.Message, add func(pendingTorrent) string) {
+	chatID := message.Chat.ID
+
+	b.mu.Lock()
+	batch, ok := b.pendingBatches[chatID]
+	var items []pendingTorrent
+	if ok {
+		n := len(batch.Items)
+		if batch.PerItem && n > 0 {
+			n = 1
+		}
+		items = batch.Items[:n]
+		batch.Items = batch.Items[n:]
+	}
+	b.mu.Unlock()
+	if !ok {
+		b.sendErrorMessage(chatID, "No pending torrents")
+		return
+	}
+
+	results := make([]string, 0, len(items))
+	for _, item := range items {
+		results = append(results, add(item))
+	}
+
+	b.mu.Lock()
+	batch.Results = append(batch.Results, results...)
+	b.mu.Unlock()
+
+	b.showBatch(message, batch)
+}
+
+// showBatch edits the batch message to ask for the next category, or to
+// show the summary once every torrent was handled
+func (b *Bot) showBatch(message *tgbotapi.Message, batch *pendingBatch) {
+	chatID := message.Chat.ID
+
+	b.mu.Lock()
+	done := len(batch.Items) == 0
+	if done && b.pendingBatches[chatID] == batch {
+		delete(b.pendingBatches, chatID)
+	}
+	var text string
+	switch {
+	case done:
+		text = formatBatchSummary(batch)
+	case batch.PerItem:
+		text = formatBatchItemPrompt(batch)
+	default:
+		text = formatBatchPrompt(batch)
+	}
+	perItem := batch.PerItem
+	b.mu.Unlock()
+
+	if done {
+		b.editMessageText(message, text)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, message.MessageID, text, CreateBatchKeyboard(b.config.TorrentCategories, perItem))
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Error updating batch message: %v", err)
+	}
+}
+
+// setPendingBatch remembers a batch waiting for category selection,
+// replacing any single torrent waiting in the same chat
+func (b *Bot) setPendingBatch(chatID int64, batch *pendingBatch) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pendingTorrents, chatID)
+	b.pendingBatches[chatID] = batch
+}
+
+// hasPendingBatch reports whether a batch is waiting for category selection
+func (b *Bot) hasPendingBatch(chatID int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.pendingBatches[chatID]
+	return ok
+}
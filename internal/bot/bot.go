@@ -1,28 +1,53 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"telegramBot/internal/client"
 	"telegramBot/internal/config"
+	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
+	"telegramBot/internal/webhook"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// maxCaptionLength is the longest caption Telegram accepts on a document
+const maxCaptionLength = 1024
+
 // Bot represents the Telegram bot
 type Bot struct {
-	api              *tgbotapi.BotAPI
-	config           *config.Config
-	qbtClient        *client.QBittorrentClient
-	trackerClient    *client.TorrentTrackerClient
-	torrentLinkRegex *regexp.Regexp
-	trackerRegex     *regexp.Regexp
-	pendingLinks     map[int64]string
+	api           *tgbotapi.BotAPI
+	config        *config.Config
+	qbtClient     *client.QBittorrentClient
+	trackerClient *client.TorrentTrackerClient
+	torznab       *client.TorznabClient // nil when Torznab is not configured
+
+	// mu guards the state below, which is shared between update handlers
+	// and background monitors
+	mu              sync.Mutex
+	pendingTorrents map[int64]pendingTorrent
+	pendingBatches  map[int64]*pendingBatch
+	searches        map[int64]*searchSession
+	logSubscribers  map[int64]bool
+	logViews        map[int64]logView
+	torrentOwners   map[string]int64
+	captchas        map[captchaKey]pendingCaptcha
+	watches         map[string]*watchedTopic
+}
+
+// pendingTorrent is a torrent waiting for the user to choose a category
+type pendingTorrent struct {
+	Tracker string
+	ID      string
+	Data    []byte // Torrent file already downloaded from a direct link
+	Link    string // Magnet or download link from a Torznab search
 }
 
 // NewBot creates a new instance of the Telegram bot
@@ -40,23 +65,50 @@ func NewBot(config *config.Config) (*Bot, error) {
 	}
 
 	// Initialize torrent tracker client
-	trackerClient, err := client.NewTorrentTrackerClient(config.TrackerCredentials)
+	var sessionStore *client.SessionStore
+	if config.TrackerSessions.Dir != "" {
+		sessionStore, err = client.NewSessionStore(config.TrackerSessions.Dir, config.TrackerSessions.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tracker session store: %w", err)
+		}
+	}
+	trackerClient, err := client.NewTorrentTrackerClient(config.TrackerCredentials, sessionStore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tracker client: %w", err)
 	}
 
-	// Compile regex patterns
-	torrentLinkRegex := regexp.MustCompile(`(http|https)://(kinozal|rutracker)\.[a-z]{2,4}\b([-a-zA-Z0-9@:%_+.~#?&/=]*)`)
-	trackerRegex := regexp.MustCompile("kinozal|rutracker")
+	var torznab *client.TorznabClient
+	if config.Torznab.URL != "" {
+		torznab, err = client.NewTorznabClient(config.Torznab)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Torznab client: %w", err)
+		}
+	}
+
+	watches, err := loadWatches(config.Watch.File)
+	if err != nil {
+		return nil, err
+	}
+
+	torrentOwners, err := loadTorrentOwners(config.Webhook.OwnersFile)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Bot{
-		api:              bot,
-		config:           config,
-		qbtClient:        qbtClient,
-		trackerClient:    trackerClient,
-		torrentLinkRegex: torrentLinkRegex,
-		trackerRegex:     trackerRegex,
-		pendingLinks:     make(map[int64]string),
+		api:             bot,
+		config:          config,
+		qbtClient:       qbtClient,
+		trackerClient:   trackerClient,
+		torznab:         torznab,
+		pendingTorrents: make(map[int64]pendingTorrent),
+		pendingBatches:  make(map[int64]*pendingBatch),
+		searches:        make(map[int64]*searchSession),
+		logSubscribers:  make(map[int64]bool),
+		logViews:        make(map[int64]logView),
+		torrentOwners:   torrentOwners,
+		captchas:        make(map[captchaKey]pendingCaptcha),
+		watches:         watches,
 	}, nil
 }
 
@@ -72,6 +124,24 @@ func (b *Bot) Start() error {
 	// Log bot info
 	log.Printf("Authorized on account %s", b.api.Self.UserName)
 
+	// Start background monitors
+	go b.monitorLog()
+	go b.monitorDiskSpace()
+	go b.monitorWatches()
+
+	// Start the completion webhook server if configured
+	if b.config.Webhook.ListenAddr != "" {
+		server, err := webhook.NewServer(b.config.Webhook.ListenAddr, b.config.Webhook.Secret, b.handleTorrentCompleted)
+		if err != nil {
+			return fmt.Errorf("failed to create webhook server: %w", err)
+		}
+		go func() {
+			if err := server.Start(); err != nil {
+				log.Printf("Webhook server error: %v", err)
+			}
+		}()
+	}
+
 	// Process updates
 	for update := range updates {
 		go b.handleUpdate(update)
@@ -93,9 +163,8 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 		return
 	}
 
-	// Try to match torrent links in messages
-	if b.torrentLinkRegex.MatchString(update.Message.Text) {
-		b.handleTorrentLink(update.Message)
+	// Answers to tracker captchas are replies to the captcha picture
+	if b.handleCaptchaReply(update.Message) {
 		return
 	}
 
@@ -104,6 +173,33 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 		b.handleCommand(update.Message)
 		return
 	}
+
+	// Links make the bot log in to trackers and fetch pages, so they are
+	// only handled for allowed users
+	if !slices.Contains(b.config.AllowedUsers, update.Message.Chat.ID) {
+		return
+	}
+
+	// Split the links of the message into tracker links and other links
+	var trackerLinks, otherLinks []string
+	for _, link := range messageLinks(update.Message) {
+		if _, _, ok := b.trackerClient.MatchLink(link); ok {
+			trackerLinks = append(trackerLinks, link)
+		} else {
+			otherLinks = append(otherLinks, link)
+		}
+	}
+
+	// Try to match torrent links in messages
+	switch {
+	case len(trackerLinks) == 1:
+		b.handleTorrentLink(update.Message.Chat.ID, trackerLinks[0])
+	case len(trackerLinks) > 1:
+		b.handleTorrentLinks(update.Message.Chat.ID, trackerLinks)
+	case len(otherLinks) > 0:
+		// Try other links as direct .torrent downloads
+		b.handleDirectTorrentLink(update.Message, otherLinks[0])
+	}
 }
 
 // handleCallbackQuery processes callbacks from inline keyboards
@@ -118,10 +214,15 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 	b.api.Request(callback)
 
 	// Handle torrent category selection (for downloads)
-	if strings.HasSuffix(data, ".") && b.pendingLinks[chatID] != "" {
-		torrentLink := b.pendingLinks[chatID]
-		b.handleTorrentDownload(chatID, messageID, torrentLink, data)
-		return
+	if strings.HasSuffix(data, ".") {
+		if b.hasPendingBatch(chatID) {
+			b.handleBatchDownload(query.Message, data)
+			return
+		}
+		if pending, ok := b.getPendingTorrent(chatID); ok {
+			b.handleTorrentDownload(query.Message, pending, data)
+			return
+		}
 	}
 
 	// Handle torrent management actions
@@ -147,6 +248,28 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		case "pause", "resume", "delete", "deletewithdata", "info":
 			// Perform actions on a specific torrent
 			b.handleTorrentAction(chatID, messageID, action, parts[1])
+		case "export":
+			// Send the .torrent file and magnet link back to the user
+			b.handleTorrentExport(chatID, parts[1])
+		case "log":
+			// Handle log pagination
+			if len(parts) > 2 {
+				b.handleLogPagination(chatID, messageID, parts[1], parts[2])
+			}
+		case "search":
+			// Handle search result pagination and selection
+			if len(parts) > 2 {
+				index, _ := strconv.Atoi(parts[2])
+				b.handleSearchCallback(chatID, messageID, parts[1], index)
+			}
+		case "batch":
+			// Choose categories per torrent or skip a torrent of a batch
+			b.handleBatchCallback(query.Message, parts[1])
+		case "unwatch":
+			// Stop watching a topic, the key is tracker:id
+			if len(parts) > 2 {
+				b.handleUnwatch(chatID, messageID, parts[1]+":"+parts[2])
+			}
 		case "list":
 			// Handle list pagination
 			if len(parts) > 2 && parts[1] == "page" {
@@ -163,30 +286,71 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 	b.sendErrorMessage(chatID, "Unknown callback data")
 }
 
-// handleTorrentLink processes a message containing a torrent link
-func (b *Bot) handleTorrentLink(message *tgbotapi.Message) {
-	chatID := message.Chat.ID
+// handleTorrentLink processes a tracker link sent in a message
+func (b *Bot) handleTorrentLink(chatID int64, link string) {
+	// Extract tracker and ID from link
+	trackerName, id, err := ProcessTorrentLink(b.trackerClient, link)
+	if err != nil {
+		b.sendErrorMessage(chatID, fmt.Sprintf("Error processing link: %v", err))
+		return
+	}
+
+	// Describe the topic, rejecting topics that can't be downloaded
+	topic, err := b.trackerClient.Topic(trackerName, id)
+	switch {
+	case errors.Is(err, client.ErrTopicClosed), errors.Is(err, client.ErrTopicAbsorbed):
+		b.sendErrorMessage(chatID, fmt.Sprintf("This torrent can't be downloaded: %v", err))
+		return
+	case err != nil:
+		if b.requestCaptcha(chatID, err) {
+			return
+		}
+		log.Printf("Error getting topic %s from %s: %v", id, trackerName, err)
+	}
 
-	// Store the link for later processing
-	b.pendingLinks[chatID] = message.Text
+	// Store the torrent for later processing
+	b.setPendingTorrent(chatID, pendingTorrent{Tracker: trackerName, ID: id})
 
 	// Send category selection keyboard
-	msg := tgbotapi.NewMessage(chatID, "What category should this download be saved as?")
-	msg.ReplyMarkup = CreateCategoryKeyboard(b.config.TorrentCategories)
+	b.sendCategoryPrompt(chatID, topic)
+}
+
+// handleDirectTorrentLink downloads a .torrent file from a link to another
+// site and offers the category selection if it is a valid torrent
+func (b *Bot) handleDirectTorrentLink(message *tgbotapi.Message, link string) {
+	chatID := message.Chat.ID
+
+	torrentBytes, err := b.trackerClient.DownloadDirect(link)
+	if err != nil {
+		b.sendErrorMessage(chatID, fmt.Sprintf("Error processing link: %v", err))
+		return
+	}
 
-	_, err := b.api.Send(msg)
+	meta, err := utils.ParseTorrentMeta(torrentBytes)
 	if err != nil {
+		b.sendErrorMessage(chatID, fmt.Sprintf("Error processing link: %v", err))
+		return
+	}
+
+	// Store the downloaded file for later processing
+	b.setPendingTorrent(chatID, pendingTorrent{Data: torrentBytes})
+
+	text := fmt.Sprintf("📦 %s (%s)\n\n%s", meta.Name, formatSize(meta.TotalSize), categoryPrompt)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = CreateCategoryKeyboard(b.config.TorrentCategories)
+
+	if _, err := b.api.Send(msg); err != nil {
 		log.Printf("Error sending category keyboard: %v", err)
 		b.sendErrorMessage(chatID, "Failed to send keyboard")
 	}
 }
 
 // handleTorrentDownload processes a torrent download request after category selection
-func (b *Bot) handleTorrentDownload(chatID int64, messageID int, torrentLink, categoryKey string) {
+func (b *Bot) handleTorrentDownload(message *tgbotapi.Message, pending pendingTorrent, categoryKey string) {
+	chatID := message.Chat.ID
+
 	// Edit the message to show processing
-	edit := tgbotapi.NewEditMessageText(chatID, messageID, "Processing download request...")
-	edit.ReplyMarkup = nil
-	b.api.Send(edit)
+	b.editMessageText(message, "Processing download request...")
 
 	// Get category save path
 	category, exists := b.config.TorrentCategories[categoryKey]
@@ -195,26 +359,55 @@ func (b *Bot) handleTorrentDownload(chatID int64, messageID int, torrentLink, ca
 		return
 	}
 
-	// Extract tracker and ID from link
-	trackerName, id, err := ProcessTorrentLink(torrentLink)
-	if err != nil {
-		b.sendErrorMessage(chatID, fmt.Sprintf("Error processing link: %v", err))
-		return
+	// Download and add torrent, unless it came from a direct link or Torznab
+	var torrent *models.TorrentInfo
+	var result string
+	var err error
+	switch {
+	case pending.Data != nil:
+		torrent, result, err = AddTorrentBytes(b.qbtClient, b.checkFreeSpace, pending.Data, category.SavePath)
+	case pending.Link != "":
+		torrent, result, err = b.addTorznabResult(pending.Link, category.SavePath)
+	default:
+		torrent, result, err = DownloadAndAddTorrent(b.trackerClient, b.qbtClient, b.checkFreeSpace, pending.Tracker, pending.ID, category.SavePath)
 	}
-
-	// Download and add torrent
-	result, err := DownloadAndAddTorrent(b.trackerClient, b.qbtClient, trackerName, id, category.SavePath)
 	if err != nil {
-		b.sendErrorMessage(chatID, fmt.Sprintf("Download failed: %v", err))
+		b.sendClientError(chatID, "downloading torrent", err)
 		return
 	}
 
+	// Remember who added the torrent to notify them when it completes
+	b.trackTorrentOwner(torrent.Hash, chatID)
+
 	// Update message with success
-	edit = tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("✅ %s\n\nSave path: %s", result, category.SavePath))
-	b.api.Send(edit)
+	b.editMessageText(message, fmt.Sprintf("✅ %s\n\nSave path: %s", result, category.SavePath))
+
+	// Clear the pending torrent
+	b.clearPendingTorrent(chatID)
+}
 
-	// Clear the pending link
-	delete(b.pendingLinks, chatID)
+// setPendingTorrent remembers a torrent waiting for category selection,
+// replacing any batch waiting in the same chat
+func (b *Bot) setPendingTorrent(chatID int64, pending pendingTorrent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pendingBatches, chatID)
+	b.pendingTorrents[chatID] = pending
+}
+
+// getPendingTorrent returns the torrent waiting for category selection
+func (b *Bot) getPendingTorrent(chatID int64) (pendingTorrent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pending, ok := b.pendingTorrents[chatID]
+	return pending, ok
+}
+
+// clearPendingTorrent forgets the torrent waiting for category selection
+func (b *Bot) clearPendingTorrent(chatID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pendingTorrents, chatID)
 }
 
 // handleCommand processes bot commands
@@ -243,6 +436,24 @@ func (b *Bot) handleCommand(message *tgbotapi.Message) {
 		b.handleReconnectCommand(chatID)
 	case "password":
 		b.handlePasswordCommand(chatID)
+	case "log":
+		b.handleLogCommand(chatID, args)
+	case "prefs":
+		b.handlePrefsCommand(chatID, args)
+	case "watch":
+		b.handleWatchCommand(chatID, args)
+	case "rt":
+		b.handleSearchCommand(chatID, command, args, b.trackerSearch("rutracker"))
+	case "kz":
+		b.handleSearchCommand(chatID, command, args, b.trackerSearch("kinozal"))
+	case "trackers":
+		b.handleTrackersCommand(chatID)
+	case "find":
+		if b.torznab == nil {
+			b.sendErrorMessage(chatID, "Torznab search is not configured")
+			return
+		}
+		b.handleSearchCommand(chatID, command, args, b.torznab.Search)
 	default:
 		msg := tgbotapi.NewMessage(chatID, "Unknown command. Type /help for available commands.")
 		b.api.Send(msg)
@@ -257,14 +468,28 @@ func (b *Bot) handleHelpCommand(chatID int64) {
 /torrent [name] - Search for torrents by name
 /list - Show a list of active torrents
 /password - Generate a random password
+/log [level] - Show the qBittorrent log (all, info, warning, critical)
+/log subscribe - Get warnings and critical log entries as messages (admins)
+/prefs - Show qBittorrent preferences
+/prefs set [key] [value] - Change a preference (admins)
+/watch [link] - Add new versions of a topic's torrent automatically
+/watch - List watched topics
+/rt [query] - Search RuTracker
+/kz [query] - Search Kinozal
+/find [query] - Search all indexers through Jackett or Prowlarr
+/trackers - Check tracker logins, ratio and bonus points
 
 *Other Features:*
 - Send a link from a supported tracker to download it
+- Send several tracker links in one message to add them as a batch
+- Send a direct link to a .torrent file on any site
 - Use buttons to manage your torrents
 
 *Supported Trackers:*
-- RuTracker
-- Kinozal`
+`
+	for _, tracker := range b.trackerClient.Trackers() {
+		helpText += "- " + tracker.DisplayName() + "\n"
+	}
 
 	msg := tgbotapi.NewMessage(chatID, helpText)
 	msg.ParseMode = "Markdown"
@@ -275,18 +500,8 @@ func (b *Bot) handleHelpCommand(chatID int64) {
 func (b *Bot) handleStatusCommand(chatID int64) {
 	status, keyboard, err := HandleTorrentStatus(b.qbtClient, 0)
 	if err != nil {
-		// Try to reconnect and retry
-		if b.tryReconnect(chatID, "getting torrent status") {
-			// Retry after successful reconnection
-			status, keyboard, err = HandleTorrentStatus(b.qbtClient, 0)
-			if err != nil {
-				b.sendErrorMessage(chatID, fmt.Sprintf("Error getting status even after reconnection: %v", err))
-				return
-			}
-		} else {
-			// Reconnection failed
-			return
-		}
+		b.sendClientError(chatID, "getting torrent status", err)
+		return
 	}
 
 	msg := tgbotapi.NewMessage(chatID, status)
@@ -297,36 +512,6 @@ func (b *Bot) handleStatusCommand(chatID int64) {
 	b.api.Send(msg)
 }
 
-// tryReconnect attempts to reconnect to qBittorrent and returns whether it was successful
-func (b *Bot) tryReconnect(chatID int64, operation string) bool {
-	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Lost connection to qBittorrent while %s. Attempting to reconnect...", operation))
-	sentMsg, _ := b.api.Send(msg)
-
-	// Attempt to reconnect
-	err := b.qbtClient.Reconnect()
-	if err != nil {
-		edit := tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID,
-			fmt.Sprintf("❌ Failed to reconnect: %v", err))
-		b.api.Send(edit)
-		return false
-	}
-
-	// Test the connection
-	_, err = b.qbtClient.GetTorrents("")
-	if err != nil {
-		edit := tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID,
-			fmt.Sprintf("❌ Reconnection failed during testing: %v", err))
-		b.api.Send(edit)
-		return false
-	}
-
-	// Update message with success
-	edit := tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID,
-		"✅ Successfully reconnected to qBittorrent. Retrying operation...")
-	b.api.Send(edit)
-	return true
-}
-
 // handleTorrentCommand shows details for specific torrents
 func (b *Bot) handleTorrentCommand(chatID int64, args string) {
 	if args == "" {
@@ -337,18 +522,8 @@ func (b *Bot) handleTorrentCommand(chatID int64, args string) {
 
 	text, keyboard, err := HandleSpecificTorrentStatus(b.qbtClient, args)
 	if err != nil {
-		// Try to reconnect and retry
-		if b.tryReconnect(chatID, "searching for torrents") {
-			// Retry after successful reconnection
-			text, keyboard, err = HandleSpecificTorrentStatus(b.qbtClient, args)
-			if err != nil {
-				b.sendErrorMessage(chatID, fmt.Sprintf("Error even after reconnection: %v", err))
-				return
-			}
-		} else {
-			// Reconnection failed
-			return
-		}
+		b.sendClientError(chatID, "searching for torrents", err)
+		return
 	}
 
 	msg := tgbotapi.NewMessage(chatID, text)
@@ -363,18 +538,8 @@ func (b *Bot) handleTorrentCommand(chatID int64, args string) {
 func (b *Bot) handleListCommand(chatID int64) {
 	torrents, err := b.qbtClient.GetTorrents("")
 	if err != nil {
-		// Try to reconnect and retry
-		if b.tryReconnect(chatID, "listing torrents") {
-			// Retry after successful reconnection
-			torrents, err = b.qbtClient.GetTorrents("")
-			if err != nil {
-				b.sendErrorMessage(chatID, fmt.Sprintf("Error getting torrent list even after reconnection: %v", err))
-				return
-			}
-		} else {
-			// Reconnection failed
-			return
-		}
+		b.sendClientError(chatID, "listing torrents", err)
+		return
 	}
 
 	if len(torrents) == 0 {
@@ -405,26 +570,8 @@ func (b *Bot) handlePasswordCommand(chatID int64) {
 func (b *Bot) handleTorrentDetails(chatID int64, messageID int, hash string, page int) {
 	text, keyboard, err := HandleSpecificTorrentStatus(b.qbtClient, "manage:"+hash)
 	if err != nil {
-		// Send a temporary message about the error
-		tempMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Error accessing torrent details: %v", err))
-		sentMsg, _ := b.api.Send(tempMsg)
-
-		// Try to reconnect
-		if b.tryReconnect(chatID, "getting torrent details") {
-			// Delete the temporary message
-			deleteMsg := tgbotapi.NewDeleteMessage(chatID, sentMsg.MessageID)
-			b.api.Request(deleteMsg)
-
-			// Retry after successful reconnection
-			text, keyboard, err = HandleSpecificTorrentStatus(b.qbtClient, "manage:"+hash)
-			if err != nil {
-				b.sendErrorMessage(chatID, fmt.Sprintf("Error even after reconnection: %v", err))
-				return
-			}
-		} else {
-			// Reconnection failed
-			return
-		}
+		b.sendClientError(chatID, "getting torrent details", err)
+		return
 	}
 
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
@@ -439,26 +586,8 @@ func (b *Bot) handleTorrentDetails(chatID int64, messageID int, hash string, pag
 func (b *Bot) handleTorrentAction(chatID int64, messageID int, action, hash string) {
 	text, keyboard, err := HandleTorrentAction(b.qbtClient, action, hash)
 	if err != nil {
-		// Send a temporary message about the error
-		tempMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Error performing action %s: %v", action, err))
-		sentMsg, _ := b.api.Send(tempMsg)
-
-		// Try to reconnect
-		if b.tryReconnect(chatID, "performing torrent action") {
-			// Delete the temporary message
-			deleteMsg := tgbotapi.NewDeleteMessage(chatID, sentMsg.MessageID)
-			b.api.Request(deleteMsg)
-
-			// Retry after successful reconnection
-			text, keyboard, err = HandleTorrentAction(b.qbtClient, action, hash)
-			if err != nil {
-				b.sendErrorMessage(chatID, fmt.Sprintf("Error even after reconnection: %v", err))
-				return
-			}
-		} else {
-			// Reconnection failed
-			return
-		}
+		b.sendClientError(chatID, "performing action "+action, err)
+		return
 	}
 
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
@@ -469,6 +598,46 @@ func (b *Bot) handleTorrentAction(chatID int64, messageID int, action, hash stri
 	b.api.Send(edit)
 }
 
+// handleTorrentExport sends a torrent's .torrent file and magnet link to the chat
+func (b *Bot) handleTorrentExport(chatID int64, hash string) {
+	torrent, err := b.qbtClient.GetTorrentByHash(hash)
+	if err != nil {
+		b.sendClientError(chatID, "finding torrent", err)
+		return
+	}
+
+	torrentBytes, err := b.qbtClient.ExportTorrent(hash)
+	if err != nil {
+		b.sendClientError(chatID, "exporting torrent", err)
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  torrentFileName(torrent.Name),
+		Bytes: torrentBytes,
+	})
+
+	// Telegram captions are limited, so long magnet links go in a separate message
+	var magnet string
+	if meta, err := utils.ParseTorrentMeta(torrentBytes); err != nil {
+		log.Printf("Error parsing exported torrent: %v", err)
+	} else {
+		magnet = meta.MagnetLink()
+	}
+	if magnet != "" && len(magnet) <= maxCaptionLength {
+		doc.Caption = magnet
+		magnet = ""
+	}
+
+	if _, err := b.api.Send(doc); err != nil {
+		b.sendErrorMessage(chatID, fmt.Sprintf("Error sending torrent file: %v", err))
+		return
+	}
+	if magnet != "" {
+		b.api.Send(tgbotapi.NewMessage(chatID, magnet))
+	}
+}
+
 // handleReconnectCommand forces a reconnection to qBittorrent
 func (b *Bot) handleReconnectCommand(chatID int64) {
 	// Send a message indicating we're attempting to reconnect
@@ -501,17 +670,31 @@ func (b *Bot) handleReconnectCommand(chatID int64) {
 	b.api.Send(edit)
 }
 
+// isAdmin reports whether the chat belongs to a bot admin
+func (b *Bot) isAdmin(chatID int64) bool {
+	return slices.Contains(b.config.AdminUsers, chatID)
+}
+
 // sendErrorMessage sends an error message to the user
 func (b *Bot) sendErrorMessage(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, "❌ "+text)
 	b.api.Send(msg)
 }
 
+// sendClientError sends a single friendly message for an error from the client
+// layer, forwarding tracker captchas to the admins
+func (b *Bot) sendClientError(chatID int64, operation string, err error) {
+	if b.requestCaptcha(chatID, err) {
+		return
+	}
+	b.sendErrorMessage(chatID, describeError(operation, err))
+}
+
 // handleListPagination handles pagination for the torrent list
 func (b *Bot) handleListPagination(chatID int64, messageID int, page int) {
 	torrents, err := b.qbtClient.GetTorrents("")
 	if err != nil {
-		b.sendErrorMessage(chatID, fmt.Sprintf("Error getting torrent list: %v", err))
+		b.sendClientError(chatID, "getting torrent list", err)
 		return
 	}
 
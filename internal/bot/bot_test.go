@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"telegramBot/internal/client"
+	"telegramBot/internal/client/qbtfake"
+	"telegramBot/internal/config"
+	"telegramBot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sentMessage is a message the bot sent or edited through the Telegram stub
+type sentMessage struct {
+	Method string
+	ChatID int64
+	Text   string
+}
+
+// telegramStub records what the bot sends to the Telegram Bot API
+type telegramStub struct {
+	mu       sync.Mutex
+	messages []sentMessage
+}
+
+// ServeHTTP answers getMe and records every other method as a sent message
+func (s *telegramStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	w.Header().Set("Content-Type", "application/json")
+	if method == "getMe" {
+		fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"test_bot"}}`)
+		return
+	}
+
+	chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	s.mu.Lock()
+	s.messages = append(s.messages, sentMessage{Method: method, ChatID: chatID, Text: r.FormValue("text")})
+	id := len(s.messages)
+	s.mu.Unlock()
+
+	fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d,"chat":{"id":%d}}}`, id, chatID)
+}
+
+// sent returns the messages recorded so far
+func (s *telegramStub) sent() []sentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sentMessage(nil), s.messages...)
+}
+
+// newTestBot returns a bot talking to a Telegram stub and a fake qBittorrent.
+// Rutor downloads go to trackerServer when it is set. Chat 100 is an admin.
+func newTestBot(t *testing.T, trackerServer *httptest.Server) (*Bot, *telegramStub, *qbtfake.Server) {
+	t.Helper()
+
+	stub := &telegramStub{}
+	telegram := httptest.NewServer(stub)
+	t.Cleanup(telegram.Close)
+	api, err := tgbotapi.NewBotAPIWithClient("token", telegram.URL+"/bot%s/%s", telegram.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+
+	credentials := make(map[string]models.TrackerCredentials)
+	if trackerServer != nil {
+		credentials["rutor"] = models.TrackerCredentials{DownloadURL: trackerServer.URL}
+	}
+	trackerClient, err := client.NewTorrentTrackerClient(credentials, nil)
+	if err != nil {
+		t.Fatalf("NewTorrentTrackerClient: %v", err)
+	}
+
+	qbtClient, qbt := newTestQBittorrent(t)
+	dir := t.TempDir()
+
+	b := &Bot{
+		api: api,
+		config: &config.Config{
+			AdminUsers: []int64{100},
+			Watch:      config.WatchConfig{File: filepath.Join(dir, "watches.json")},
+			Webhook:    config.WebhookConfig{OwnersFile: filepath.Join(dir, "owners.json")},
+		},
+		qbtClient:       qbtClient,
+		trackerClient:   trackerClient,
+		pendingTorrents: make(map[int64]pendingTorrent),
+		pendingBatches:  make(map[int64]*pendingBatch),
+		searches:        make(map[int64]*searchSession),
+		logSubscribers:  make(map[int64]bool),
+		logViews:        make(map[int64]logView),
+		torrentOwners:   make(map[string]int64),
+		captchas:        make(map[captchaKey]pendingCaptcha),
+		watches:         make(map[string]*watchedTopic),
+	}
+	return b, stub, qbt
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"telegramBot/internal/client"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// captchaKey identifies the captcha message sent to an admin
+type captchaKey struct {
+	ChatID    int64
+	MessageID int
+}
+
+// pendingCaptcha is a tracker captcha waiting for an admin's answer
+type pendingCaptcha struct {
+	Challenge   *client.CaptchaChallenge
+	RequestedBy int64 // Chat whose request needed the login
+}
+
+// requestCaptcha forwards a tracker captcha to the admins if err asks for
+// one, and tells the requesting chat to try again later. It reports whether
+// err was a captcha request.
+func (b *Bot) requestCaptcha(chatID int64, err error) bool {
+	var captchaErr *client.CaptchaError
+	if !errors.As(err, &captchaErr) {
+		return false
+	}
+	challenge := captchaErr.Challenge
+
+	image, err := b.trackerClient.CaptchaImage(challenge)
+	if err != nil {
+		log.Printf("Error getting %s captcha: %v", challenge.Tracker, err)
+		b.sendErrorMessage(chatID, fmt.Sprintf("%s asks for a captcha, but it could not be loaded: %v", challenge.Tracker, err))
+		return true
+	}
+
+	b.mu.Lock()
+	// Only the newest challenge of a tracker can be answered
+	for key, pending := range b.captchas {
+		if pending.Challenge.Tracker == challenge.Tracker {
+			delete(b.captchas, key)
+		}
+	}
+	b.mu.Unlock()
+
+	caption := fmt.Sprintf("🔐 %s asks for a captcha to log in.\nReply to this message with the text in the picture.", challenge.Tracker)
+	for _, adminID := range b.config.AdminUsers {
+		photo := tgbotapi.NewPhoto(adminID, tgbotapi.FileBytes{Name: "captcha.jpg", Bytes: image})
+		photo.Caption = caption
+
+		sent, err := b.api.Send(photo)
+		if err != nil {
+			log.Printf("Error sending captcha to admin %d: %v", adminID, err)
+			continue
+		}
+
+		b.mu.Lock()
+		b.captchas[captchaKey{ChatID: adminID, MessageID: sent.MessageID}] = pendingCaptcha{
+			Challenge:   challenge,
+			RequestedBy: chatID,
+		}
+		b.mu.Unlock()
+	}
+
+	if !b.isAdmin(chatID) {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔐 %s asks for a captcha. An admin has been asked to solve it, please try again afterwards.", challenge.Tracker))
+		b.api.Send(msg)
+	}
+	return true
+}
+
+// handleCaptchaReply resubmits a tracker login with an admin's answer to a
+// captcha. It reports whether the message was a captcha answer.
+func (b *Bot) handleCaptchaReply(message *tgbotapi.Message) bool {
+	if message.ReplyToMessage == nil {
+		return false
+	}
+
+	key := captchaKey{ChatID: message.Chat.ID, MessageID: message.ReplyToMessage.MessageID}
+	b.mu.Lock()
+	pending, ok := b.captchas[key]
+	if ok {
+		delete(b.captchas, key)
+	}
+	b.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	answer := strings.TrimSpace(message.Text)
+	err := b.trackerClient.SolveCaptcha(pending.Challenge, answer)
+	if err != nil {
+		// A wrong answer comes back as a new captcha
+		if !b.requestCaptcha(pending.RequestedBy, err) {
+			b.sendErrorMessage(message.Chat.ID, fmt.Sprintf("Login to %s failed: %v", pending.Challenge.Tracker, err))
+		}
+		return true
+	}
+
+	// The other admins' copies of this captcha are no longer needed
+	b.mu.Lock()
+	for key, other := range b.captchas {
+		if other.Challenge == pending.Challenge {
+			delete(b.captchas, key)
+		}
+	}
+	b.mu.Unlock()
+
+	text := fmt.Sprintf("✅ Logged in to %s", pending.Challenge.Tracker)
+	b.api.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+	if pending.RequestedBy != message.Chat.ID {
+		b.api.Send(tgbotapi.NewMessage(pending.RequestedBy, text+", please try again."))
+	}
+	return true
+}
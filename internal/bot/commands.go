@@ -1,8 +1,11 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
-	"regexp"
+	"log"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -69,6 +72,48 @@ func formatETA(eta int64) string {
 	}
 }
 
+// formatState returns a readable torrent state, falling back to the raw
+// qBittorrent value for states we don't know about
+func formatState(t *models.TorrentInfo) string {
+	if state := t.Status(); state != models.StateUnknown {
+		return state.String()
+	}
+	return t.State
+}
+
+// torrentFileName turns a torrent name into a safe .torrent file name
+func torrentFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+
+	if name == "" {
+		name = "download"
+	}
+	return name + ".torrent"
+}
+
+// describeError turns an error from the client layer into a single friendly message
+func describeError(operation string, err error) string {
+	var loginErr *client.LoginError
+	switch {
+	case errors.As(err, &loginErr):
+		// Tracker login errors carry the tracker's own message
+		return "🔑 " + loginErr.Error()
+	case errors.Is(err, client.ErrBackendUnavailable):
+		return "qBittorrent is not reachable right now. Please try again in a minute."
+	case errors.Is(err, client.ErrUnauthorized):
+		return "qBittorrent rejected the bot's login. Please check the qBittorrent credentials."
+	case errors.Is(err, client.ErrNotFound):
+		return "Torrent not found. It may have been removed."
+	default:
+		return fmt.Sprintf("Error %s: %v", operation, err)
+	}
+}
+
 // HandlePasswordCommand generates a random password
 func HandlePasswordCommand(wordListPath string) (string, error) {
 	password, err := utils.GeneratePassword(wordListPath)
@@ -104,32 +149,33 @@ func HandleTorrentStatus(qbt *client.QBittorrentClient, page int) (string, tgbot
 	// Display torrents for current page
 	for _, t := range torrents[startIndex:endIndex] {
 		// Format the display based on torrent state
-		switch t.State {
-		case "downloading":
+		state := t.Status()
+		switch {
+		case state.IsDownloading():
 			sb.WriteString(fmt.Sprintf("🔽 *%s*\n", t.Name))
 			sb.WriteString("Status: Downloading\n")
 			sb.WriteString(fmt.Sprintf("Progress: %s\n", formatProgress(t.Progress)))
 			sb.WriteString(fmt.Sprintf("Speed: %s\n", formatSpeed(t.Dlspeed)))
 			sb.WriteString(fmt.Sprintf("ETA: %s\n", formatETA(t.Eta)))
-		case "uploading", "seeding":
+		case state.IsSeeding():
 			sb.WriteString(fmt.Sprintf("🔼 *%s*\n", t.Name))
 			sb.WriteString("Status: Seeding\n")
 			sb.WriteString(fmt.Sprintf("Upload Speed: %s\n", formatSpeed(t.Upspeed)))
-		case "pausedDL":
+		case state == models.StatePausedDownload:
 			sb.WriteString(fmt.Sprintf("⏸ *%s*\n", t.Name))
 			sb.WriteString("Status: Paused\n")
 			sb.WriteString(fmt.Sprintf("Progress: %s\n", formatProgress(t.Progress)))
-		case "stalledDL":
+		case state == models.StateStalledDownload:
 			sb.WriteString(fmt.Sprintf("⚠️ *%s*\n", t.Name))
 			sb.WriteString("Status: Stalled\n")
 			sb.WriteString(fmt.Sprintf("Progress: %s\n", formatProgress(t.Progress)))
-		case "checkingDL", "checkingUP":
+		case state == models.StateChecking:
 			sb.WriteString(fmt.Sprintf("🔍 *%s*\n", t.Name))
 			sb.WriteString("Status: Checking\n")
 			sb.WriteString(fmt.Sprintf("Progress: %s\n", formatProgress(t.Progress)))
 		default:
 			sb.WriteString(fmt.Sprintf("📁 *%s*\n", t.Name))
-			sb.WriteString(fmt.Sprintf("Status: %s\n", t.State))
+			sb.WriteString(fmt.Sprintf("Status: %s\n", formatState(&t)))
 		}
 
 		sb.WriteString(fmt.Sprintf("Size: %s\n", formatSize(t.Size)))
@@ -192,14 +238,14 @@ func formatTorrentDetails(t *models.TorrentInfo) string {
 	sb.WriteString(fmt.Sprintf("📥 *%s*\n\n", t.Name))
 
 	// Status and progress
-	sb.WriteString(fmt.Sprintf("Status: %s\n", t.State))
+	sb.WriteString(fmt.Sprintf("Status: %s\n", formatState(t)))
 	sb.WriteString(fmt.Sprintf("Progress: %s\n", formatProgress(t.Progress)))
 
 	// Different details based on torrent state
-	if t.State == "downloading" {
+	if state := t.Status(); state.IsDownloading() {
 		sb.WriteString(fmt.Sprintf("Download Speed: %s\n", formatSpeed(t.Dlspeed)))
 		sb.WriteString(fmt.Sprintf("ETA: %s\n", formatETA(t.Eta)))
-	} else if t.State == "uploading" || t.State == "seeding" {
+	} else if state.IsSeeding() {
 		sb.WriteString(fmt.Sprintf("Upload Speed: %s\n", formatSpeed(t.Upspeed)))
 	}
 
@@ -281,58 +327,111 @@ func HandleTorrentAction(qbt *client.QBittorrentClient, action string, hash stri
 	}
 }
 
-// ProcessTorrentLink extracts tracker info and ID from a torrent link
-func ProcessTorrentLink(link string) (string, string, error) {
-	r := regexp.MustCompile(`(http|https)://(kinozal|rutracker)\.[a-z]{2,4}\b([-a-zA-Z0-9@:%_+.~#?&/=]*)`)
-	tracker := regexp.MustCompile("kinozal|rutracker")
-
-	matches := r.FindStringSubmatch(link)
-	if matches == nil {
+// ProcessTorrentLink extracts tracker info and ID from a message containing a torrent link
+func ProcessTorrentLink(trackerClient *client.TorrentTrackerClient, text string) (string, string, error) {
+	link, ok := trackerClient.FindTrackerLink(text)
+	if !ok {
 		return "", "", fmt.Errorf("invalid torrent link format")
 	}
 
-	trackerName := tracker.FindString(link)
+	return trackerClient.ParseLink(link)
+}
 
-	// Extract ID based on tracker pattern
-	var id string
-	if trackerName == "rutracker" {
-		idRegex := regexp.MustCompile(`t=(\d+)`)
-		idMatches := idRegex.FindStringSubmatch(link)
-		if len(idMatches) < 2 {
-			return "", "", fmt.Errorf("could not extract ID from rutracker link")
-		}
-		id = idMatches[1]
-	} else if trackerName == "kinozal" {
-		idRegex := regexp.MustCompile(`id=(\d+)`)
-		idMatches := idRegex.FindStringSubmatch(link)
-		if len(idMatches) < 2 {
-			return "", "", fmt.Errorf("could not extract ID from kinozal link")
+// SpaceChecker verifies there is room for a torrent of the given size in savePath.
+// It returns a warning to show the user, or an error if the torrent must be refused.
+type SpaceChecker func(savePath string, size int64) (string, error)
+
+// DownloadAndAddTorrent downloads a torrent from a tracker and adds it to qBittorrent
+func DownloadAndAddTorrent(trackerClient *client.TorrentTrackerClient, qbtClient *client.QBittorrentClient, checkSpace SpaceChecker, trackerName, id, savePath string) (*models.TorrentInfo, string, error) {
+	// Prefer the magnet link for trackers that publish one
+	magnet, err := trackerClient.Magnet(trackerName, id)
+	if err != nil {
+		log.Printf("Falling back to torrent file for %s %s: %v", trackerName, id, err)
+	}
+	if magnet != "" {
+		return AddTorrentLink(qbtClient, checkSpace, magnet, savePath)
+	}
+
+	// Download torrent file from tracker
+	torrentBytes, err := trackerClient.DownloadTorrent(trackerName, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download torrent: %w", err)
+	}
+
+	return AddTorrentBytes(qbtClient, checkSpace, torrentBytes, savePath)
+}
+
+// AddTorrentLink adds a torrent from a magnet link. The size is only known
+// when the link carries it in xl, otherwise the free space is checked against
+// the configured minimum alone.
+func AddTorrentLink(qbtClient *client.QBittorrentClient, checkSpace SpaceChecker, link, savePath string) (*models.TorrentInfo, string, error) {
+	var warning string
+	if checkSpace != nil {
+		var err error
+		warning, err = checkSpace(savePath, magnetSize(link))
+		if err != nil {
+			return nil, "", err
 		}
-		id = idMatches[1]
-	} else {
-		return "", "", fmt.Errorf("unsupported tracker: %s", trackerName)
 	}
 
-	return trackerName, id, nil
+	torrent, err := qbtClient.AddTorrentURL(link, savePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to add torrent to qBittorrent: %w", err)
+	}
+
+	result := fmt.Sprintf("Magnet link successfully added to download queue:\n📥 *%s*\n📂 Category: %s\n💾 Save Path: %s",
+		torrent.Name,
+		torrent.Category,
+		torrent.SavePath)
+	if warning != "" {
+		result += "\n\n" + warning
+	}
+
+	return torrent, result, nil
 }
 
-// DownloadAndAddTorrent downloads a torrent from a tracker and adds it to qBittorrent
-func DownloadAndAddTorrent(trackerClient *client.TorrentTrackerClient, qbtClient *client.QBittorrentClient, trackerName, id, savePath string) (string, error) {
-	// Download torrent file from tracker
-	torrentBytes, err := trackerClient.DownloadTorrent(trackerName, id)
+// magnetSize returns the size a magnet link declares in its xl parameter,
+// or 0 if it declares none
+func magnetSize(link string) int64 {
+	u, err := url.Parse(link)
 	if err != nil {
-		return "", fmt.Errorf("failed to download torrent: %w", err)
+		return 0
+	}
+	size, err := strconv.ParseInt(u.Query().Get("xl"), 10, 64)
+	if err != nil || size < 0 {
+		return 0
+	}
+	return size
+}
+
+// AddTorrentBytes checks free space for a torrent file and adds it to qBittorrent.
+// It returns the added torrent and a message describing the result.
+func AddTorrentBytes(qbtClient *client.QBittorrentClient, checkSpace SpaceChecker, torrentBytes []byte, savePath string) (*models.TorrentInfo, string, error) {
+	// Compare the torrent size with the free space before adding it
+	var warning string
+	if meta, err := utils.ParseTorrentMeta(torrentBytes); err != nil {
+		log.Printf("Skipping free space check, failed to parse torrent: %v", err)
+	} else if checkSpace != nil {
+		warning, err = checkSpace(savePath, meta.TotalSize)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	// Add torrent to qBittorrent
 	torrent, err := qbtClient.AddTorrent(torrentBytes, savePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to add torrent to qBittorrent: %w", err)
+		return nil, "", fmt.Errorf("failed to add torrent to qBittorrent: %w", err)
 	}
 
 	// Create a more detailed success message
-	return fmt.Sprintf("Torrent successfully added to download queue:\n📥 *%s*\n📂 Category: %s\n💾 Save Path: %s",
+	result := fmt.Sprintf("Torrent successfully added to download queue:\n📥 *%s*\n📂 Category: %s\n💾 Save Path: %s",
 		torrent.Name,
 		torrent.Category,
-		torrent.SavePath), nil
+		torrent.SavePath)
+	if warning != "" {
+		result += "\n\n" + warning
+	}
+
+	return torrent, result, nil
 }
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"telegramBot/internal/client"
+	"telegramBot/internal/client/qbtfake"
+	"telegramBot/internal/models"
+)
+
+// newTestQBittorrent starts a fake qBittorrent and returns a client connected to it
+func newTestQBittorrent(t *testing.T) (*client.QBittorrentClient, *qbtfake.Server) {
+	t.Helper()
+
+	server := qbtfake.New("admin", "secret")
+	t.Cleanup(server.Close)
+
+	q, err := client.NewQBittorrentClient(models.QBittorrentCredentials{
+		URL:      server.URL,
+		Username: "admin",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewQBittorrentClient: %v", err)
+	}
+	return q, server
+}
+
+func TestAddTorrentLinkChecksSpace(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	magnet := "magnet:?xt=urn:btih:" + hash + "&dn=Movie&xl=4294967296"
+	q, server := newTestQBittorrent(t)
+
+	var checkedSize int64 = -1
+	refuse := func(savePath string, size int64) (string, error) {
+		checkedSize = size
+		return "", errors.New("refusing download: only 1 GB would be left")
+	}
+	if _, _, err := AddTorrentLink(q, refuse, magnet, "/downloads"); err == nil {
+		t.Fatal("AddTorrentLink added a magnet the space check refused")
+	}
+	if checkedSize != 4<<30 {
+		t.Errorf("space checked for %d bytes, want the magnet's xl of %d", checkedSize, int64(4<<30))
+	}
+	if len(server.AddedURLs()) != 0 {
+		t.Errorf("refused magnet reached qBittorrent: %v", server.AddedURLs())
+	}
+
+	warn := func(savePath string, size int64) (string, error) {
+		return "⚠️ Low disk space", nil
+	}
+	_, result, err := AddTorrentLink(q, warn, magnet, "/downloads")
+	if err != nil {
+		t.Fatalf("AddTorrentLink: %v", err)
+	}
+	if !strings.HasSuffix(result, "⚠️ Low disk space") {
+		t.Errorf("result = %q, want the space warning", result)
+	}
+	if _, ok := server.Torrent(hash); !ok {
+		t.Error("magnet was not added")
+	}
+}
+
+func TestMagnetSize(t *testing.T) {
+	tests := []struct {
+		link string
+		want int64
+	}{
+		{"magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&xl=1048576", 1 << 20},
+		{"magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567", 0},
+		{"magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&xl=-5", 0},
+		{"magnet:?xl=big", 0},
+	}
+
+	for _, tt := range tests {
+		if got := magnetSize(tt.link); got != tt.want {
+			t.Errorf("magnetSize(%q) = %d, want %d", tt.link, got, tt.want)
+		}
+	}
+}
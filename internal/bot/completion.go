@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// loadTorrentOwners reads the chats that added unfinished torrents from a
+// JSON file. A missing file means no torrents are tracked yet.
+func loadTorrentOwners(path string) (map[string]int64, error) {
+	owners := make(map[string]int64)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return owners, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read torrent owners: %w", err)
+	}
+	if err := json.Unmarshal(data, &owners); err != nil {
+		return nil, fmt.Errorf("failed to parse torrent owners: %w", err)
+	}
+	return owners, nil
+}
+
+// saveTorrentOwnersLocked writes the torrent owners to disk. b.mu must be held.
+func (b *Bot) saveTorrentOwnersLocked() {
+	data, err := json.MarshalIndent(b.torrentOwners, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding torrent owners: %v", err)
+		return
+	}
+	if err := os.WriteFile(b.config.Webhook.OwnersFile, data, 0o600); err != nil {
+		log.Printf("Error saving torrent owners: %v", err)
+	}
+}
+
+// trackTorrentOwner remembers which chat added a torrent
+func (b *Bot) trackTorrentOwner(hash string, chatID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.torrentOwners[strings.ToLower(hash)] = chatID
+	b.saveTorrentOwnersLocked()
+}
+
+// popTorrentOwner returns and forgets the chat that added a torrent
+func (b *Bot) popTorrentOwner(hash string) (int64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hash = strings.ToLower(hash)
+	chatID, ok := b.torrentOwners[hash]
+	if ok {
+		delete(b.torrentOwners, hash)
+		b.saveTorrentOwnersLocked()
+	}
+	return chatID, ok
+}
+
+// handleTorrentCompleted notifies the chat that added a finished torrent.
+// Admins are notified when the torrent wasn't added through the bot.
+func (b *Bot) handleTorrentCompleted(hash string) {
+	torrent, err := b.qbtClient.GetTorrentByHash(hash)
+	if err != nil {
+		log.Printf("Error getting completed torrent %s: %v", hash, err)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Download complete: %s\n\nSize: %s\nSave Path: %s",
+		torrent.Name, formatSize(torrent.Size), torrent.SavePath)
+
+	chatID, ok := b.popTorrentOwner(torrent.Hash)
+	if !ok {
+		b.notifyAdmins(text)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = CreateTorrentActionsKeyboard(torrent.Hash)
+	b.api.Send(msg)
+}
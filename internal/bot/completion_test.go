@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"path/filepath"
+	"testing"
+
+	"telegramBot/internal/config"
+)
+
+func TestTorrentOwnersPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.json")
+	newBot := func() *Bot {
+		owners, err := loadTorrentOwners(path)
+		if err != nil {
+			t.Fatalf("loadTorrentOwners: %v", err)
+		}
+		return &Bot{config: &config.Config{Webhook: config.WebhookConfig{OwnersFile: path}}, torrentOwners: owners}
+	}
+
+	b := newBot()
+	b.trackTorrentOwner("AAAA", 1)
+	b.trackTorrentOwner("bbbb", 2)
+
+	// Owners survive a restart and are forgotten once notified
+	b = newBot()
+	if chatID, ok := b.popTorrentOwner("aaaa"); !ok || chatID != 1 {
+		t.Errorf("owner of aaaa = %d, %v, want 1", chatID, ok)
+	}
+	b = newBot()
+	if _, ok := b.popTorrentOwner("aaaa"); ok {
+		t.Error("popped owner is still saved")
+	}
+	if chatID, ok := b.popTorrentOwner("BBBB"); !ok || chatID != 2 {
+		t.Errorf("owner of bbbb = %d, %v, want 2", chatID, ok)
+	}
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"telegramBot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// diskSpacePollInterval is how often free space is checked for admin alerts
+const diskSpacePollInterval = 5 * time.Minute
+
+// freeSpace returns the free space available for savePath. Category paths are
+// checked locally when enabled, otherwise qBittorrent's own value is used.
+func (b *Bot) freeSpace(savePath string) (int64, error) {
+	if b.config.DiskSpace.CheckLocal && savePath != "" {
+		free, err := utils.FreeDiskSpace(savePath)
+		if err == nil {
+			return free, nil
+		}
+		log.Printf("Local disk space check failed, falling back to qBittorrent: %v", err)
+	}
+
+	return b.qbtClient.GetFreeSpace()
+}
+
+// checkFreeSpace compares a torrent's size with the free space for savePath.
+// It returns a warning for the user, or an error if the torrent must be refused.
+func (b *Bot) checkFreeSpace(savePath string, size int64) (string, error) {
+	free, err := b.freeSpace(savePath)
+	if err != nil {
+		// Don't block downloads just because the check itself failed
+		log.Printf("Error checking free disk space: %v", err)
+		return "", nil
+	}
+
+	if size > free {
+		return "", fmt.Errorf("not enough free space: torrent needs %s, only %s free",
+			formatSize(size), formatSize(free))
+	}
+
+	minFree := b.config.DiskSpace.MinFree
+	if remaining := free - size; remaining < minFree {
+		message := fmt.Sprintf("only %s would be left on disk after this download (limit is %s)",
+			formatSize(remaining), formatSize(minFree))
+		if b.config.DiskSpace.Refuse {
+			return "", fmt.Errorf("refusing download: %s", message)
+		}
+		return "⚠️ Low disk space: " + message, nil
+	}
+
+	return "", nil
+}
+
+// monitorDiskSpace alerts admins when free space drops below the configured limit
+func (b *Bot) monitorDiskSpace() {
+	if b.config.DiskSpace.AlertBelow <= 0 {
+		return
+	}
+
+	// The empty path stands for qBittorrent's default save path
+	paths := []string{""}
+	if b.config.DiskSpace.CheckLocal {
+		for _, category := range b.config.TorrentCategories {
+			if category.SavePath != "" {
+				paths = append(paths, category.SavePath)
+			}
+		}
+	}
+
+	alerted := make(map[string]bool)
+
+	ticker := time.NewTicker(diskSpacePollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, path := range paths {
+			free, err := b.freeSpace(path)
+			if err != nil {
+				log.Printf("Error checking free disk space: %v", err)
+				continue
+			}
+
+			// Alert once when crossing the limit and reset when space is freed
+			low := free < b.config.DiskSpace.AlertBelow
+			if low && !alerted[path] {
+				location := path
+				if location == "" {
+					location = "qBittorrent default save path"
+				}
+				b.notifyAdmins(fmt.Sprintf("💾 Low disk space on %s: %s free (limit is %s)",
+					location, formatSize(free), formatSize(b.config.DiskSpace.AlertBelow)))
+			}
+			alerted[path] = low
+		}
+	}
+}
+
+// notifyAdmins sends a plain text message to every admin
+func (b *Bot) notifyAdmins(text string) {
+	for _, chatID := range b.config.AdminUsers {
+		b.api.Send(tgbotapi.NewMessage(chatID, text))
+	}
+}
@@ -33,6 +33,7 @@ func CreateTorrentActionsKeyboard(hash string) tgbotapi.InlineKeyboardMarkup {
 	deleteCallback := "delete:" + hash
 	deleteWithDataCallback := "deletewithdata:" + hash
 	infoCallback := "info:" + hash
+	exportCallback := "export:" + hash
 
 	// Create keyboard rows
 	row1 := tgbotapi.NewInlineKeyboardRow(
@@ -42,6 +43,7 @@ func CreateTorrentActionsKeyboard(hash string) tgbotapi.InlineKeyboardMarkup {
 
 	row2 := tgbotapi.NewInlineKeyboardRow(
 		tgbotapi.NewInlineKeyboardButtonData("ℹ️ Info", infoCallback),
+		tgbotapi.NewInlineKeyboardButtonData("📤 Export", exportCallback),
 	)
 
 	row3 := tgbotapi.NewInlineKeyboardRow(
@@ -107,3 +109,90 @@ func CreateTorrentListKeyboard(torrents []models.TorrentInfo, maxButtons int, cu
 
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
+
+// CreateLogKeyboard creates a keyboard for paging through the qBittorrent log
+func CreateLogKeyboard(types models.LogType, oldestID int64, hasMore bool) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	if hasMore {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			"⬆️ More",
+			fmt.Sprintf("log:%d:%d", types, oldestID),
+		))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+		"🔄 Latest",
+		fmt.Sprintf("log:%d:0", types),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// CreateSearchResultsKeyboard creates numbered buttons for the search results
+// from start to end with pagination
+func CreateSearchResultsKeyboard(start, end, currentPage, totalPages int) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	// Four result buttons per row
+	var row []tgbotapi.InlineKeyboardButton
+	for i := start; i < end; i++ {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%d", i+1),
+			fmt.Sprintf("search:pick:%d", i),
+		))
+		if len(row) == 4 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	// Add pagination buttons
+	var paginationRow []tgbotapi.InlineKeyboardButton
+	if currentPage > 0 {
+		paginationRow = append(paginationRow,
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Previous", fmt.Sprintf("search:page:%d", currentPage-1)),
+		)
+	}
+	if currentPage < totalPages-1 {
+		paginationRow = append(paginationRow,
+			tgbotapi.NewInlineKeyboardButtonData("Next ➡️", fmt.Sprintf("search:page:%d", currentPage+1)),
+		)
+	}
+	if len(paginationRow) > 0 {
+		rows = append(rows, paginationRow)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// CreateWatchListKeyboard creates a button per watched topic to stop watching it
+func CreateWatchListKeyboard(watches []*watchedTopic) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, w := range watches {
+		title := w.Title
+		if runes := []rune(title); len(runes) > 30 {
+			title = string(runes[:27]) + "..."
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("❌ %d. %s", i+1, title), "unwatch:"+w.key()),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// CreateBatchKeyboard creates the category keyboard for a batch of torrents,
+// with a button to choose categories one by one, or to skip the current
+// torrent once categories are chosen per item
+func CreateBatchKeyboard(categories map[string]models.TorrentCategory, perItem bool) tgbotapi.InlineKeyboardMarkup {
+	keyboard := CreateCategoryKeyboard(categories)
+
+	button := tgbotapi.NewInlineKeyboardButtonData("🔀 Choose per torrent", "batch:peritem")
+	if perItem {
+		button = tgbotapi.NewInlineKeyboardButtonData("⏭ Skip", "batch:skip")
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(button))
+
+	return keyboard
+}
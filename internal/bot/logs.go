@@ -0,0 +1,283 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"telegramBot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// logEntriesPerPage is how many log entries are shown in one message
+	logEntriesPerPage = 15
+	// logMessageMaxLen is the maximum length of a single log message shown in chat
+	logMessageMaxLen = 300
+	// logPageMaxLen is the longest log page sent, below Telegram's limit of
+	// 4096 characters per message
+	logPageMaxLen = 4000
+	// logPollInterval is how often the log is checked for subscribers
+	logPollInterval = time.Minute
+	// logSubscriptionTypes are the severities pushed to subscribed admins
+	logSubscriptionTypes = models.LogWarning | models.LogCritical
+)
+
+// logView is the log fetched by /log in a chat, kept so paging through it
+// doesn't download the whole log again for every page
+type logView struct {
+	types   models.LogType
+	entries []models.LogEntry
+}
+
+// parseLogLevel converts a minimum severity name to a mask of log types
+func parseLogLevel(level string) (models.LogType, error) {
+	switch strings.ToLower(level) {
+	case "", "all", "normal":
+		return models.LogNormal | models.LogInfo | models.LogWarning | models.LogCritical, nil
+	case "info":
+		return models.LogInfo | models.LogWarning | models.LogCritical, nil
+	case "warning", "warn":
+		return models.LogWarning | models.LogCritical, nil
+	case "critical":
+		return models.LogCritical, nil
+	default:
+		return 0, fmt.Errorf("unknown log level: %s", level)
+	}
+}
+
+// logTypeIcon returns an icon for a log severity
+func logTypeIcon(t models.LogType) string {
+	switch t {
+	case models.LogCritical:
+		return "🔴"
+	case models.LogWarning:
+		return "⚠️"
+	case models.LogInfo:
+		return "ℹ️"
+	default:
+		return "▫️"
+	}
+}
+
+// formatLogEntry formats a single log entry as one line of plain text
+func formatLogEntry(entry models.LogEntry) string {
+	message := entry.Message
+	// Cut by runes, qBittorrent logs may contain non-ASCII paths
+	if runes := []rune(message); len(runes) > logMessageMaxLen {
+		message = string(runes[:logMessageMaxLen-3]) + "..."
+	}
+
+	// qBittorrent reports timestamps in seconds
+	timestamp := time.Unix(entry.Timestamp, 0).Format("2006-01-02 15:04:05")
+	return fmt.Sprintf("%s %s %s", logTypeIcon(entry.Type), timestamp, message)
+}
+
+// HandleLogPage returns a page of log entries older than beforeID (or the
+// latest entries if beforeID is 0) together with a keyboard for paging
+func HandleLogPage(entries []models.LogEntry, types models.LogType, beforeID int64) (string, tgbotapi.InlineKeyboardMarkup) {
+	// Keep only entries older than the cursor
+	end := len(entries)
+	if beforeID > 0 {
+		for end > 0 && entries[end-1].ID >= beforeID {
+			end--
+		}
+	}
+
+	if end == 0 {
+		return "No log entries found", tgbotapi.InlineKeyboardMarkup{}
+	}
+
+	const header = "📜 qBittorrent log:\n\n"
+
+	// Take the newest entries that fit both the page size and the message
+	// length, the first one always fits as messages are cut short
+	var lines []string
+	length := telegramLength(header)
+	start := end
+	for start > 0 && len(lines) < logEntriesPerPage {
+		line := formatLogEntry(entries[start-1]) + "\n"
+		if length+telegramLength(line) > logPageMaxLen {
+			break
+		}
+		length += telegramLength(line)
+		lines = append(lines, line)
+		start--
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	for i := len(lines) - 1; i >= 0; i-- {
+		sb.WriteString(lines[i])
+	}
+
+	return sb.String(), CreateLogKeyboard(types, entries[start].ID, start > 0)
+}
+
+// telegramLength returns the length of a text as Telegram counts it, in
+// UTF-16 code units
+func telegramLength(text string) int {
+	n := 0
+	for _, r := range text {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// handleLogCommand shows the latest qBittorrent log entries or manages subscriptions
+func (b *Bot) handleLogCommand(chatID int64, args string) {
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "subscribe":
+		b.handleLogSubscription(chatID, true)
+		return
+	case "unsubscribe":
+		b.handleLogSubscription(chatID, false)
+		return
+	}
+
+	types, err := parseLogLevel(strings.TrimSpace(args))
+	if err != nil {
+		b.sendErrorMessage(chatID, fmt.Sprintf("%v. Use one of: all, info, warning, critical", err))
+		return
+	}
+
+	entries, err := b.qbtClient.GetLog(types, -1)
+	if err != nil {
+		b.sendClientError(chatID, "reading the log", err)
+		return
+	}
+
+	b.mu.Lock()
+	b.logViews[chatID] = logView{types: types, entries: entries}
+	b.mu.Unlock()
+
+	text, keyboard := HandleLogPage(entries, types, 0)
+	msg := tgbotapi.NewMessage(chatID, text)
+	if len(keyboard.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = keyboard
+	}
+	if _, err := b.api.Send(msg); err != nil {
+		log.Printf("Error sending log page: %v", err)
+	}
+}
+
+// handleLogPagination shows older log entries in place of the current page
+func (b *Bot) handleLogPagination(chatID int64, messageID int, typesArg, beforeArg string) {
+	types, err := strconv.Atoi(typesArg)
+	if err != nil {
+		b.sendErrorMessage(chatID, "Invalid log filter")
+		return
+	}
+	beforeID, err := strconv.ParseInt(beforeArg, 10, 64)
+	if err != nil {
+		b.sendErrorMessage(chatID, "Invalid log position")
+		return
+	}
+
+	// Older pages come from the log fetched by /log, the latest page and
+	// pages of a log no longer kept are fetched again
+	b.mu.Lock()
+	view, ok := b.logViews[chatID]
+	b.mu.Unlock()
+	if !ok || view.types != models.LogType(types) || beforeID == 0 {
+		entries, err := b.qbtClient.GetLog(models.LogType(types), -1)
+		if err != nil {
+			b.sendClientError(chatID, "reading the log", err)
+			return
+		}
+		view = logView{types: models.LogType(types), entries: entries}
+
+		b.mu.Lock()
+		b.logViews[chatID] = view
+		b.mu.Unlock()
+	}
+
+	text, keyboard := HandleLogPage(view.entries, view.types, beforeID)
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	if len(keyboard.InlineKeyboard) > 0 {
+		edit.ReplyMarkup = &keyboard
+	}
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Error updating log page: %v", err)
+	}
+}
+
+// handleLogSubscription subscribes or unsubscribes an admin from log alerts
+func (b *Bot) handleLogSubscription(chatID int64, subscribe bool) {
+	if !b.isAdmin(chatID) {
+		b.sendErrorMessage(chatID, "Only admins can subscribe to log alerts")
+		return
+	}
+
+	b.mu.Lock()
+	if subscribe {
+		b.logSubscribers[chatID] = true
+	} else {
+		delete(b.logSubscribers, chatID)
+	}
+	b.mu.Unlock()
+
+	text := "🔕 Unsubscribed from qBittorrent log alerts"
+	if subscribe {
+		text = "🔔 Subscribed to qBittorrent warnings and critical log entries"
+	}
+	if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		log.Printf("Error confirming log subscription: %v", err)
+	}
+}
+
+// monitorLog periodically pushes new warning and critical log entries to subscribed admins
+func (b *Bot) monitorLog() {
+	// Only the first poll reads the whole log, later polls ask for entries
+	// after the last one seen
+	lastKnownID := int64(-1)
+	initialized := false
+	paused := false
+
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.mu.Lock()
+		subscribers := make([]int64, 0, len(b.logSubscribers))
+		for chatID := range b.logSubscribers {
+			subscribers = append(subscribers, chatID)
+		}
+		b.mu.Unlock()
+
+		// Don't poll while nobody listens, but keep the position so a new
+		// subscriber skips the entries logged meanwhile
+		if len(subscribers) == 0 {
+			paused = true
+			continue
+		}
+
+		entries, err := b.qbtClient.GetLog(logSubscriptionTypes, lastKnownID)
+		if err != nil {
+			log.Printf("Error polling qBittorrent log: %v", err)
+			continue
+		}
+		if len(entries) > 0 {
+			lastKnownID = entries[len(entries)-1].ID
+		}
+
+		// On the first poll or after a pause only remember the position
+		if !initialized || paused {
+			initialized, paused = true, false
+			continue
+		}
+
+		for _, entry := range entries {
+			text := "🚨 qBittorrent " + entry.Type.String() + ":\n" + formatLogEntry(entry)
+			for _, chatID := range subscribers {
+				if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+					log.Printf("Error sending log alert to %d: %v", chatID, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"telegramBot/internal/models"
+)
+
+func TestFormatLogEntry(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+
+	// qBittorrent reports the time in seconds since the epoch
+	entry := models.LogEntry{ID: 7, Message: "Torrent added", Timestamp: 1700000000, Type: models.LogWarning}
+	if got, want := formatLogEntry(entry), "⚠️ 2023-11-14 22:13:20 Torrent added"; got != want {
+		t.Errorf("formatLogEntry = %q, want %q", got, want)
+	}
+
+	entry.Message = strings.Repeat("я", logMessageMaxLen+10)
+	if got := []rune(formatLogEntry(entry)); !strings.HasSuffix(string(got), "...") || len(got) > logMessageMaxLen+25 {
+		t.Errorf("long message not cut: %d runes", len(got))
+	}
+}
+
+func TestHandleLogPage(t *testing.T) {
+	types := models.LogNormal | models.LogWarning
+	var entries []models.LogEntry
+	for id := int64(1); id <= 40; id++ {
+		entries = append(entries, models.LogEntry{ID: id, Message: strings.Repeat("ж", logMessageMaxLen), Timestamp: 1700000000, Type: models.LogNormal})
+	}
+
+	// Long messages fill a page before the entry limit
+	text, keyboard := HandleLogPage(entries, types, 0)
+	if n := telegramLength(text); n > logPageMaxLen {
+		t.Errorf("page is %d characters long, want at most %d", n, logPageMaxLen)
+	}
+	shown := strings.Count(text, "\n") - 2
+	if shown == 0 || shown >= logEntriesPerPage {
+		t.Fatalf("page shows %d long entries, want fewer than %d", shown, logEntriesPerPage)
+	}
+
+	// The next page starts right before the oldest entry shown
+	oldest := int64(40 - shown + 1)
+	if got := keyboard.InlineKeyboard[0][0].CallbackData; got == nil || *got != "log:"+strconv.Itoa(int(types))+":"+strconv.FormatInt(oldest, 10) {
+		t.Errorf("more button = %v, want the entries before %d", got, oldest)
+	}
+
+	// Short messages are limited by the entry count
+	for i := range entries {
+		entries[i].Message = "short"
+	}
+	text, _ = HandleLogPage(entries, types, 10)
+	if shown := strings.Count(text, "\n") - 2; shown != 9 {
+		t.Errorf("page before entry 10 shows %d entries, want 9", shown)
+	}
+	text, _ = HandleLogPage(entries, types, 0)
+	if shown := strings.Count(text, "\n") - 2; shown != logEntriesPerPage {
+		t.Errorf("latest page shows %d entries, want %d", shown, logEntriesPerPage)
+	}
+}
@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"telegramBot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// prefKind is the type of value a preference accepts
+type prefKind int
+
+const (
+	prefInt prefKind = iota
+	prefBool
+	prefFloat
+	prefString
+)
+
+// preference describes a qBittorrent preference that admins may change
+type preference struct {
+	Key   string
+	Label string
+	Kind  prefKind
+	Min   float64
+	Max   float64
+}
+
+// editablePreferences lists the preferences that can be changed with /prefs set.
+// A limit of -1 means unlimited in qBittorrent.
+var editablePreferences = []preference{
+	{Key: "max_active_downloads", Label: "Max active downloads", Kind: prefInt, Min: -1, Max: 10000},
+	{Key: "max_active_uploads", Label: "Max active uploads", Kind: prefInt, Min: -1, Max: 10000},
+	{Key: "max_active_torrents", Label: "Max active torrents", Kind: prefInt, Min: -1, Max: 10000},
+	{Key: "queueing_enabled", Label: "Queueing enabled", Kind: prefBool},
+	{Key: "listen_port", Label: "Listen port", Kind: prefInt, Min: 1, Max: 65535},
+	{Key: "save_path", Label: "Default save path", Kind: prefString},
+	{Key: "max_ratio_enabled", Label: "Ratio limit enabled", Kind: prefBool},
+	{Key: "max_ratio", Label: "Ratio limit", Kind: prefFloat, Min: 0, Max: 9998},
+	{Key: "max_seeding_time_enabled", Label: "Seeding time limit enabled", Kind: prefBool},
+	{Key: "max_seeding_time", Label: "Seeding time limit (minutes)", Kind: prefInt, Min: 0, Max: 525600},
+}
+
+// findPreference looks up an editable preference by key
+func findPreference(key string) (preference, bool) {
+	for _, p := range editablePreferences {
+		if p.Key == key {
+			return p, true
+		}
+	}
+	return preference{}, false
+}
+
+// parseValue converts and validates a value for the preference
+func (p preference) parseValue(value string) (any, error) {
+	switch p.Kind {
+	case prefInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number", p.Key)
+		}
+		if float64(n) < p.Min || float64(n) > p.Max {
+			return nil, fmt.Errorf("%s must be between %g and %g", p.Key, p.Min, p.Max)
+		}
+		return n, nil
+	case prefFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", p.Key)
+		}
+		if f < p.Min || f > p.Max {
+			return nil, fmt.Errorf("%s must be between %g and %g", p.Key, p.Min, p.Max)
+		}
+		return f, nil
+	case prefBool:
+		switch strings.ToLower(value) {
+		case "true", "on", "yes", "1":
+			return true, nil
+		case "false", "off", "no", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%s must be on or off", p.Key)
+	case prefString:
+		if value == "" {
+			return nil, fmt.Errorf("%s must not be empty", p.Key)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unsupported preference type for %s", p.Key)
+	}
+}
+
+// formatLimit formats a qBittorrent limit where -1 means unlimited
+func formatLimit(n int) string {
+	if n < 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(n)
+}
+
+// formatToggle formats a boolean preference
+func formatToggle(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// formatPreferences formats the preferences shown by /prefs
+func formatPreferences(p *models.Preferences) string {
+	var sb strings.Builder
+	sb.WriteString("⚙️ qBittorrent preferences:\n\n")
+	sb.WriteString(fmt.Sprintf("Max active downloads: %s\n", formatLimit(p.MaxActiveDownloads)))
+	sb.WriteString(fmt.Sprintf("Max active uploads: %s\n", formatLimit(p.MaxActiveUploads)))
+	sb.WriteString(fmt.Sprintf("Max active torrents: %s\n", formatLimit(p.MaxActiveTorrents)))
+	sb.WriteString(fmt.Sprintf("Queueing: %s\n", formatToggle(p.QueueingEnabled)))
+	sb.WriteString(fmt.Sprintf("Listen port: %d\n", p.ListenPort))
+	sb.WriteString(fmt.Sprintf("Default save path: %s\n", p.SavePath))
+	sb.WriteString(fmt.Sprintf("Ratio limit: %s (%.2f)\n", formatToggle(p.MaxRatioEnabled), p.MaxRatio))
+	sb.WriteString(fmt.Sprintf("Seeding time limit: %s (%d min)\n", formatToggle(p.MaxSeedingTimeEnabled), p.MaxSeedingTime))
+	return sb.String()
+}
+
+// prefsUsage describes how to change preferences
+func prefsUsage() string {
+	var sb strings.Builder
+	sb.WriteString("Usage: /prefs set <key> <value>\n\nKeys:\n")
+	for _, p := range editablePreferences {
+		sb.WriteString(fmt.Sprintf("%s - %s\n", p.Key, p.Label))
+	}
+	return sb.String()
+}
+
+// handlePrefsCommand shows qBittorrent preferences or changes one of them
+func (b *Bot) handlePrefsCommand(chatID int64, args string) {
+	fields := strings.Fields(args)
+	if len(fields) > 0 {
+		if strings.ToLower(fields[0]) != "set" || len(fields) < 3 {
+			b.api.Send(tgbotapi.NewMessage(chatID, prefsUsage()))
+			return
+		}
+
+		// Save paths may contain spaces, so the value is the rest of the arguments
+		rest := strings.TrimSpace(strings.TrimSpace(args)[len(fields[0]):])
+		key, value, _ := strings.Cut(rest, " ")
+		b.handlePrefsSet(chatID, key, strings.TrimSpace(value))
+		return
+	}
+
+	prefs, err := b.qbtClient.GetPreferences()
+	if err != nil {
+		b.sendClientError(chatID, "reading preferences", err)
+		return
+	}
+
+	text := formatPreferences(prefs)
+	if b.isAdmin(chatID) {
+		text += "\nChange a setting with /prefs set <key> <value>"
+	}
+	b.api.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+// handlePrefsSet validates and applies a single preference change
+func (b *Bot) handlePrefsSet(chatID int64, key, value string) {
+	if !b.isAdmin(chatID) {
+		b.sendErrorMessage(chatID, "Only admins can change preferences")
+		return
+	}
+
+	pref, ok := findPreference(strings.ToLower(key))
+	if !ok {
+		b.sendErrorMessage(chatID, fmt.Sprintf("Unknown preference: %s\n\n%s", key, prefsUsage()))
+		return
+	}
+
+	parsed, err := pref.parseValue(value)
+	if err != nil {
+		b.sendErrorMessage(chatID, err.Error())
+		return
+	}
+
+	if err := b.qbtClient.SetPreferences(map[string]any{pref.Key: parsed}); err != nil {
+		b.sendClientError(chatID, "changing preference", err)
+		return
+	}
+
+	b.api.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ %s set to %v", pref.Label, parsed)))
+}
@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"telegramBot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// searchResultsPerPage is how many search results are shown in one message
+const searchResultsPerPage = 8
+
+// searchSession holds the latest search results of a chat for paging and selection
+type searchSession struct {
+	Query   string
+	Results []models.SearchResult
+}
+
+// HandleSearchPage formats a page of search results with a keyboard to pick one
+func HandleSearchPage(session *searchSession, page int) (string, tgbotapi.InlineKeyboardMarkup) {
+	totalPages := (len(session.Results) + searchResultsPerPage - 1) / searchResultsPerPage
+	page = max(0, min(page, totalPages-1))
+
+	start := page * searchResultsPerPage
+	end := min(start+searchResultsPerPage, len(session.Results))
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 Results for \"%s\" (page %d of %d, %d found):\n\n",
+		session.Query, page+1, totalPages, len(session.Results)))
+
+	for i, result := range session.Results[start:end] {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", start+i+1, result.Title))
+
+		var details []string
+		if result.Indexer != "" {
+			details = append(details, result.Indexer)
+		}
+		if result.Section != "" {
+			details = append(details, result.Section)
+		}
+		if result.Size > 0 {
+			details = append(details, formatSize(result.Size))
+		}
+		details = append(details, fmt.Sprintf("⬆%d ⬇%d", result.Seeds, result.Leechers))
+		sb.WriteString("   " + strings.Join(details, " · ") + "\n\n")
+	}
+
+	sb.WriteString("Pick a number to download:")
+
+	return sb.String(), CreateSearchResultsKeyboard(start, end, page, totalPages)
+}
+
+// searchFunc runs a search for a query
+type searchFunc func(query string) ([]models.SearchResult, error)
+
+// trackerSearch returns a searchFunc for a tracker
+func (b *Bot) trackerSearch(trackerName string) searchFunc {
+	return func(query string) ([]models.SearchResult, error) {
+		return b.trackerClient.Search(trackerName, query)
+	}
+}
+
+// handleSearchCommand runs a search and shows the first page of results
+func (b *Bot) handleSearchCommand(chatID int64, command, query string, search searchFunc) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Please provide a search query. Example: /%s ubuntu", command))
+		b.api.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "🔎 Searching...")
+	sentMsg, err := b.api.Send(msg)
+	if err != nil {
+		return
+	}
+
+	results, err := search(query)
+	if err != nil {
+		if b.requestCaptcha(chatID, err) {
+			b.api.Request(tgbotapi.NewDeleteMessage(chatID, sentMsg.MessageID))
+			return
+		}
+		edit := tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, "❌ "+describeError("searching", err))
+		b.api.Send(edit)
+		return
+	}
+
+	if len(results) == 0 {
+		edit := tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, "No results found")
+		b.api.Send(edit)
+		return
+	}
+
+	session := &searchSession{Query: query, Results: results}
+	b.mu.Lock()
+	b.searches[chatID] = session
+	b.mu.Unlock()
+
+	text, keyboard := HandleSearchPage(session, 0)
+	edit := tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, text)
+	edit.ReplyMarkup = &keyboard
+	b.api.Send(edit)
+}
+
+// handleSearchCallback handles paging through search results and picking one
+func (b *Bot) handleSearchCallback(chatID int64, messageID int, action string, index int) {
+	b.mu.Lock()
+	session := b.searches[chatID]
+	b.mu.Unlock()
+
+	if session == nil {
+		b.sendErrorMessage(chatID, "Search results have expired, please search again")
+		return
+	}
+
+	switch action {
+	case "page":
+		text, keyboard := HandleSearchPage(session, index)
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		edit.ReplyMarkup = &keyboard
+		b.api.Send(edit)
+
+	case "pick":
+		if index < 0 || index >= len(session.Results) {
+			b.sendErrorMessage(chatID, "Invalid search result")
+			return
+		}
+		result := session.Results[index]
+
+		// Continue with the normal category selection flow
+		pending := pendingTorrent{Tracker: result.Tracker, ID: result.ID}
+		if result.Link != "" {
+			pending = pendingTorrent{Link: result.Link}
+		}
+		b.setPendingTorrent(chatID, pending)
+
+		text := fmt.Sprintf("%s\n\n%s", result.Title, categoryPrompt)
+		keyboard := CreateCategoryKeyboard(b.config.TorrentCategories)
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		edit.ReplyMarkup = &keyboard
+		b.api.Send(edit)
+
+	default:
+		b.sendErrorMessage(chatID, "Unknown search action")
+	}
+}
+
+// addTorznabResult adds a Torznab result to qBittorrent from its magnet or
+// download link
+func (b *Bot) addTorznabResult(link, savePath string) (*models.TorrentInfo, string, error) {
+	torrentBytes, magnet, err := b.torznab.Download(link)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download torrent: %w", err)
+	}
+
+	if magnet != "" {
+		return AddTorrentLink(b.qbtClient, b.checkFreeSpace, magnet, savePath)
+	}
+	return AddTorrentBytes(b.qbtClient, b.checkFreeSpace, torrentBytes, savePath)
+}
@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"telegramBot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// categoryPrompt is the question asked before adding a torrent
+const categoryPrompt = "What category should this download be saved as?"
+
+// FormatTopicCard describes a tracker topic followed by the category question
+func FormatTopicCard(topic *models.TopicInfo) string {
+	var sb strings.Builder
+	sb.WriteString("🎬 " + topic.Title + "\n")
+
+	if topic.Section != "" {
+		sb.WriteString("📂 " + topic.Section + "\n")
+	}
+
+	var details []string
+	if topic.Size > 0 {
+		details = append(details, "💾 "+formatSize(topic.Size))
+	}
+	details = append(details, fmt.Sprintf("⬆%d ⬇%d", topic.Seeds, topic.Leechers))
+	sb.WriteString(strings.Join(details, " · ") + "\n")
+
+	if topic.Registered != "" {
+		sb.WriteString("📅 Registered: " + topic.Registered + "\n")
+	}
+
+	return sb.String() + "\n" + categoryPrompt
+}
+
+// sendCategoryPrompt asks for a category, showing the topic as a photo card
+// with its poster when the tracker could describe it
+func (b *Bot) sendCategoryPrompt(chatID int64, topic *models.TopicInfo) {
+	keyboard := CreateCategoryKeyboard(b.config.TorrentCategories)
+
+	text := categoryPrompt
+	if topic != nil {
+		text = FormatTopicCard(topic)
+
+		if topic.PosterURL != "" && len(text) <= maxCaptionLength {
+			photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(topic.PosterURL))
+			photo.Caption = text
+			photo.ReplyMarkup = keyboard
+			_, err := b.api.Send(photo)
+			if err == nil {
+				return
+			}
+			// Telegram could not fetch the poster, fall back to text
+			log.Printf("Error sending topic poster: %v", err)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	if _, err := b.api.Send(msg); err != nil {
+		log.Printf("Error sending category keyboard: %v", err)
+		b.sendErrorMessage(chatID, "Failed to send keyboard")
+	}
+}
+
+// editMessageText replaces the text of a message and removes its keyboard.
+// Photo messages have their caption replaced instead.
+func (b *Bot) editMessageText(message *tgbotapi.Message, text string) {
+	if len(message.Photo) > 0 {
+		if runes := []rune(text); len(runes) > maxCaptionLength {
+			text = string(runes[:maxCaptionLength])
+		}
+		edit := tgbotapi.NewEditMessageCaption(message.Chat.ID, message.MessageID, text)
+		b.api.Send(edit)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, text)
+	b.api.Send(edit)
+}
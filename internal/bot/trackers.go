@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"telegramBot/internal/client"
+	"telegramBot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// FormatTrackerProfile describes the account statistics of a tracker
+func FormatTrackerProfile(displayName string, profile *models.TrackerProfile) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("✅ %s: logged in as %s\n", displayName, profile.Username))
+	sb.WriteString(fmt.Sprintf("   ⬆ %s · ⬇ %s", formatSize(profile.Uploaded), formatSize(profile.Downloaded)))
+
+	if profile.Downloaded > 0 {
+		sb.WriteString(fmt.Sprintf(" · ratio %.2f", profile.Ratio))
+	} else {
+		sb.WriteString(" · ratio ∞")
+	}
+	if profile.Bonus > 0 {
+		sb.WriteString(fmt.Sprintf(" · bonus %.2f", profile.Bonus))
+	}
+	sb.WriteString("\n")
+
+	if profile.AtRisk() {
+		if profile.Ratio < profile.MinRatio {
+			sb.WriteString(fmt.Sprintf("   ⚠️ Ratio is below the minimum of %.2f, downloads may be restricted\n", profile.MinRatio))
+		} else {
+			sb.WriteString(fmt.Sprintf("   ⚠️ Ratio is close to the minimum of %.2f, seed more to avoid restrictions\n", profile.MinRatio))
+		}
+	}
+	return sb.String()
+}
+
+// handleTrackersCommand logs in to every configured tracker and reports
+// the state of its account
+func (b *Bot) handleTrackersCommand(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "🔎 Checking trackers...")
+	sentMsg, err := b.api.Send(msg)
+	if err != nil {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🌐 Tracker accounts:\n\n")
+	for _, tracker := range b.trackerClient.Trackers() {
+		_, hasAccount := tracker.(client.ProfileScraper)
+		switch {
+		case !hasAccount:
+			sb.WriteString(fmt.Sprintf("🌐 %s: public tracker, no account needed\n", tracker.DisplayName()))
+			continue
+		case b.config.TrackerCredentials[tracker.Name()].Username == "":
+			sb.WriteString(fmt.Sprintf("⚪️ %s: not configured\n", tracker.DisplayName()))
+			continue
+		}
+
+		profile, err := b.trackerClient.Profile(tracker.Name())
+		if err != nil {
+			// Admins get the captcha, the report shows why the login failed
+			b.requestCaptcha(chatID, err)
+			sb.WriteString(fmt.Sprintf("❌ %s: %s\n", tracker.DisplayName(), describeError("checking account", err)))
+			continue
+		}
+		sb.WriteString(FormatTrackerProfile(tracker.DisplayName(), profile))
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, sb.String())
+	b.api.Send(edit)
+}
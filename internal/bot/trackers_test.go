@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"telegramBot/internal/models"
+)
+
+func TestFormatTrackerProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile models.TrackerProfile
+		atRisk  bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "healthy ratio",
+			profile: models.TrackerProfile{Username: "alice", Uploaded: 3 << 40, Downloaded: 1 << 40, Ratio: 3, Bonus: 12.5, MinRatio: 0.3},
+			want:    []string{"✅ RuTracker: logged in as alice", "⬆ 3.00 TB", "⬇ 1.00 TB", "ratio 3.00", "bonus 12.50"},
+			notWant: []string{"⚠️"},
+		},
+		{
+			name:    "close to the minimum",
+			profile: models.TrackerProfile{Username: "bob", Uploaded: 35 << 30, Downloaded: 100 << 30, Ratio: 0.35, MinRatio: 0.3},
+			atRisk:  true,
+			want:    []string{"ratio 0.35", "close to the minimum of 0.30"},
+			notWant: []string{"bonus"},
+		},
+		{
+			name:    "below the minimum",
+			profile: models.TrackerProfile{Username: "bob", Uploaded: 10 << 30, Downloaded: 100 << 30, Ratio: 0.1, MinRatio: 0.3},
+			atRisk:  true,
+			want:    []string{"ratio 0.10", "below the minimum of 0.30"},
+		},
+		{
+			name:    "nothing downloaded",
+			profile: models.TrackerProfile{Username: "carol", Uploaded: 1 << 20, MinRatio: 0.3},
+			want:    []string{"⬇ 0 B", "ratio ∞"},
+			notWant: []string{"⚠️"},
+		},
+		{
+			name:    "no minimum ratio",
+			profile: models.TrackerProfile{Username: "dave", Uploaded: 1 << 30, Downloaded: 100 << 30, Ratio: 0.01},
+			want:    []string{"ratio 0.01"},
+			notWant: []string{"⚠️"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.AtRisk(); got != tt.atRisk {
+				t.Errorf("AtRisk() = %v, want %v", got, tt.atRisk)
+			}
+
+			text := FormatTrackerProfile("RuTracker", &tt.profile)
+			for _, s := range tt.want {
+				if !strings.Contains(text, s) {
+					t.Errorf("FormatTrackerProfile = %q, want it to contain %q", text, s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(text, s) {
+					t.Errorf("FormatTrackerProfile = %q, want it without %q", text, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"telegramBot/internal/client"
+	"telegramBot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// watchedTopic is a tracker topic checked for re-uploaded torrents
+type watchedTopic struct {
+	Tracker  string `json:"tracker"`
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	InfoHash string `json:"info_hash"`
+	SavePath string `json:"save_path"`
+	ChatID   int64  `json:"chat_id"`
+
+	// FailedHash is the info hash of an update that could not be added.
+	// Adding it is retried, but the chat is told about the failure once.
+	FailedHash string `json:"failed_hash,omitempty"`
+}
+
+// key identifies a watched topic in callbacks and the watch list
+func (w *watchedTopic) key() string {
+	return w.Tracker + ":" + w.ID
+}
+
+// loadWatches reads the watched topics from a JSON file. A missing file
+// means nothing is watched yet.
+func loadWatches(path string) (map[string]*watchedTopic, error) {
+	watches := make(map[string]*watchedTopic)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return watches, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watched topics: %w", err)
+	}
+
+	var list []*watchedTopic
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse watched topics: %w", err)
+	}
+	for _, w := range list {
+		watches[w.key()] = w
+	}
+	return watches, nil
+}
+
+// saveWatchesLocked writes the watched topics to disk. b.mu must be held.
+func (b *Bot) saveWatchesLocked() {
+	list := make([]*watchedTopic, 0, len(b.watches))
+	for _, w := range b.watches {
+		list = append(list, w)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].key() < list[j].key() })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding watched topics: %v", err)
+		return
+	}
+	if err := os.WriteFile(b.config.Watch.File, data, 0o600); err != nil {
+		log.Printf("Error saving watched topics: %v", err)
+	}
+}
+
+// watchesOf returns the topics watched by a chat, or every topic for admins
+func (b *Bot) watchesOf(chatID int64) []*watchedTopic {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var list []*watchedTopic
+	for _, w := range b.watches {
+		if w.ChatID == chatID || b.isAdmin(chatID) {
+			list = append(list, w)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].key() < list[j].key() })
+	return list
+}
+
+// formatWatchList describes the watched topics
+func formatWatchList(watches []*watchedTopic) string {
+	if len(watches) == 0 {
+		return "No topics are being watched. Use /watch [link] to watch one."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("👀 Watched topics:\n\n")
+	for i, w := range watches {
+		sb.WriteString(fmt.Sprintf("%d. %s\n   %s · %s\n", i+1, w.Title, w.Tracker, w.SavePath))
+	}
+	sb.WriteString("\nTap a topic to stop watching it.")
+	return sb.String()
+}
+
+// handleWatchCommand starts watching a topic, or lists the watched topics
+// when no link is given
+func (b *Bot) handleWatchCommand(chatID int64, args string) {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		watches := b.watchesOf(chatID)
+		msg := tgbotapi.NewMessage(chatID, formatWatchList(watches))
+		if len(watches) > 0 {
+			msg.ReplyMarkup = CreateWatchListKeyboard(watches)
+		}
+		b.api.Send(msg)
+		return
+	}
+
+	trackerName, id, err := ProcessTorrentLink(b.trackerClient, args)
+	if err != nil {
+		b.sendErrorMessage(chatID, fmt.Sprintf("Error processing link: %v", err))
+		return
+	}
+
+	// The current torrent tells which download in qBittorrent to replace
+	torrentBytes, err := b.trackerClient.DownloadTorrent(trackerName, id)
+	if err != nil {
+		b.sendClientError(chatID, "downloading torrent", err)
+		return
+	}
+	meta, err := utils.ParseTorrentMeta(torrentBytes)
+	if err != nil {
+		b.sendErrorMessage(chatID, fmt.Sprintf("Error reading torrent: %v", err))
+		return
+	}
+
+	torrent, err := b.qbtClient.GetTorrentByHash(meta.InfoHash)
+	if errors.Is(err, client.ErrNotFound) {
+		b.sendErrorMessage(chatID, "This torrent is not in qBittorrent yet. Add it first, then watch it.")
+		return
+	}
+	if err != nil {
+		b.sendClientError(chatID, "finding torrent", err)
+		return
+	}
+
+	w := &watchedTopic{
+		Tracker:  trackerName,
+		ID:       id,
+		Title:    meta.Name,
+		InfoHash: meta.InfoHash,
+		SavePath: torrent.SavePath,
+		ChatID:   chatID,
+	}
+
+	b.mu.Lock()
+	b.watches[w.key()] = w
+	b.saveWatchesLocked()
+	b.mu.Unlock()
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("👀 Watching %s\n\nNew versions will be added to %s.", w.Title, w.SavePath))
+	b.api.Send(msg)
+}
+
+// handleUnwatch stops watching a topic and refreshes the watch list
+func (b *Bot) handleUnwatch(chatID int64, messageID int, key string) {
+	b.mu.Lock()
+	w, ok := b.watches[key]
+	allowed := ok && (w.ChatID == chatID || b.isAdmin(chatID))
+	if allowed {
+		delete(b.watches, key)
+		b.saveWatchesLocked()
+	}
+	b.mu.Unlock()
+
+	if !ok {
+		b.sendErrorMessage(chatID, "This topic is not being watched")
+		return
+	}
+	if !allowed {
+		b.sendErrorMessage(chatID, "Only the chat that watches this topic or an admin can stop watching it")
+		return
+	}
+
+	watches := b.watchesOf(chatID)
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("Stopped watching %s\n\n%s", w.Title, formatWatchList(watches)))
+	if len(watches) > 0 {
+		keyboard := CreateWatchListKeyboard(watches)
+		edit.ReplyMarkup = &keyboard
+	}
+	b.api.Send(edit)
+}
+
+// monitorWatches periodically checks the watched topics for updated torrents
+func (b *Bot) monitorWatches() {
+	ticker := time.NewTicker(b.config.Watch.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.mu.Lock()
+		watches := make([]watchedTopic, 0, len(b.watches))
+		for _, w := range b.watches {
+			watches = append(watches, *w)
+		}
+		b.mu.Unlock()
+
+		for _, w := range watches {
+			if err := b.checkWatch(w); err != nil {
+				log.Printf("Error checking watched topic %s: %v", w.key(), err)
+			}
+		}
+	}
+}
+
+// checkWatch downloads a watched topic's torrent and, if its info hash has
+// changed, adds the new torrent to the same save path and removes the old
+// one while keeping its files
+func (b *Bot) checkWatch(w watchedTopic) error {
+	torrentBytes, err := b.trackerClient.DownloadTorrent(w.Tracker, w.ID)
+	if err != nil {
+		return err
+	}
+	meta, err := utils.ParseTorrentMeta(torrentBytes)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(meta.InfoHash, w.InfoHash) {
+		return nil
+	}
+
+	_, _, err = AddTorrentBytes(b.qbtClient, b.updateSpaceChecker(w.InfoHash), torrentBytes, w.SavePath)
+	if err != nil {
+		if !strings.EqualFold(meta.InfoHash, w.FailedHash) {
+			b.api.Send(tgbotapi.NewMessage(w.ChatID, fmt.Sprintf("🔄 %s was updated, but adding the new torrent failed: %s\n\nIt will be retried without further messages.",
+				w.Title, describeError("adding torrent", err))))
+
+			b.mu.Lock()
+			if current, ok := b.watches[w.key()]; ok && current.InfoHash == w.InfoHash {
+				current.FailedHash = meta.InfoHash
+				b.saveWatchesLocked()
+			}
+			b.mu.Unlock()
+		}
+		return err
+	}
+	b.trackTorrentOwner(meta.InfoHash, w.ChatID)
+
+	// Remove the old torrent but keep its data, the new one reuses the files
+	removed := true
+	if err := b.qbtClient.DeleteTorrents([]string{w.InfoHash}, false); err != nil && !errors.Is(err, client.ErrNotFound) {
+		log.Printf("Error removing old torrent %s: %v", w.InfoHash, err)
+		removed = false
+	}
+
+	b.mu.Lock()
+	if current, ok := b.watches[w.key()]; ok && current.InfoHash == w.InfoHash {
+		current.InfoHash = meta.InfoHash
+		current.Title = meta.Name
+		current.FailedHash = ""
+		b.saveWatchesLocked()
+	}
+	b.mu.Unlock()
+
+	text := fmt.Sprintf("🔄 %s was updated on %s.\n\nThe new torrent %s was added to %s", w.Title, w.Tracker, meta.Name, w.SavePath)
+	if removed {
+		text += " and the old one was removed (its files were kept)."
+	} else {
+		text += ", but the old one could not be removed."
+	}
+	msg := tgbotapi.NewMessage(w.ChatID, text)
+	msg.ReplyMarkup = CreateTorrentActionsKeyboard(meta.InfoHash)
+	b.api.Send(msg)
+
+	return nil
+}
+
+// updateSpaceChecker returns a free space check for a torrent replacing
+// oldHash. The new torrent reuses the files the old one has downloaded, so
+// only the rest of it needs free space.
+func (b *Bot) updateSpaceChecker(oldHash string) SpaceChecker {
+	return func(savePath string, size int64) (string, error) {
+		if old, err := b.qbtClient.GetTorrentByHash(oldHash); err == nil {
+			size = max(size-(old.Size-old.AmountLeft), 0)
+		}
+		return b.checkFreeSpace(savePath, size)
+	}
+}
@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"telegramBot/internal/client/qbtfake"
+	"telegramBot/internal/utils"
+)
+
+// topicServer serves the current torrent of a watched rutor topic
+type topicServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	torrent []byte
+}
+
+// newTopicServer starts a tracker stub serving torrent for every download
+func newTopicServer(t *testing.T, torrent []byte) *topicServer {
+	t.Helper()
+	s := &topicServer{torrent: torrent}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-bittorrent")
+		w.Write(s.torrent)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// infoHash returns the info hash of a torrent made by qbtfake.MakeTorrent
+func infoHash(t *testing.T, data []byte) string {
+	t.Helper()
+	meta, err := utils.ParseTorrentMeta(data)
+	if err != nil {
+		t.Fatalf("ParseTorrentMeta: %v", err)
+	}
+	return meta.InfoHash
+}
+
+// addWatch adds the old torrent to qBittorrent and watches its topic from chat 7
+func addWatch(t *testing.T, b *Bot, old []byte) *watchedTopic {
+	t.Helper()
+	if _, err := b.qbtClient.AddTorrent(old, "/tv/series"); err != nil {
+		t.Fatalf("AddTorrent: %v", err)
+	}
+	w := &watchedTopic{Tracker: "rutor", ID: "123", Title: "Series", InfoHash: infoHash(t, old), SavePath: "/tv/series", ChatID: 7}
+	b.watches[w.key()] = w
+	return w
+}
+
+func TestCheckWatchReplacesTorrent(t *testing.T) {
+	old := qbtfake.MakeTorrent("Series.S01E01", 1<<20)
+	updated := qbtfake.MakeTorrent("Series.S01E01-02", 2<<20)
+	b, telegram, qbt := newTestBot(t, newTopicServer(t, updated).Server)
+	w := addWatch(t, b, old)
+	oldHash, newHash := w.InfoHash, infoHash(t, updated)
+
+	// The update only needs room for the part the old torrent hasn't downloaded
+	qbt.Complete(oldHash)
+	qbt.SetFreeSpace(3 << 19)
+
+	if err := b.checkWatch(*w); err != nil {
+		t.Fatalf("checkWatch: %v", err)
+	}
+
+	if torrent, ok := qbt.Torrent(newHash); !ok || torrent.SavePath != "/tv/series" {
+		t.Errorf("new torrent = %+v, %v, want it added to /tv/series", torrent, ok)
+	}
+	if deleted, deleteFiles := qbt.Deleted(oldHash); !deleted || deleteFiles {
+		t.Errorf("old torrent deleted %v with files %v, want it deleted with its files kept", deleted, deleteFiles)
+	}
+
+	watches, err := loadWatches(b.config.Watch.File)
+	if err != nil {
+		t.Fatalf("loadWatches: %v", err)
+	}
+	if saved := watches[w.key()]; saved == nil || saved.InfoHash != newHash || saved.Title != "Series.S01E01-02" {
+		t.Errorf("saved watch = %+v, want the new torrent", saved)
+	}
+	if chatID, ok := b.popTorrentOwner(newHash); !ok || chatID != 7 {
+		t.Errorf("owner of the new torrent = %d, %v, want chat 7", chatID, ok)
+	}
+
+	sent := telegram.sent()
+	if len(sent) != 1 || sent[0].ChatID != 7 || !strings.Contains(sent[0].Text, "old one was removed") {
+		t.Fatalf("messages = %+v, want one update message to chat 7", sent)
+	}
+
+	// Nothing happens while the topic keeps the same torrent
+	if err := b.checkWatch(*b.watches[w.key()]); err != nil {
+		t.Fatalf("checkWatch without changes: %v", err)
+	}
+	if sent := telegram.sent(); len(sent) != 1 {
+		t.Errorf("unchanged topic sent %+v", sent[1:])
+	}
+}
+
+func TestCheckWatchReportsFailureOnce(t *testing.T) {
+	old := qbtfake.MakeTorrent("Series.S01E01", 1<<20)
+	updated := qbtfake.MakeTorrent("Series.S01E01-02", 2<<20)
+	b, telegram, qbt := newTestBot(t, newTopicServer(t, updated).Server)
+	w := addWatch(t, b, old)
+	newHash := infoHash(t, updated)
+
+	// Nothing of the old torrent is downloaded, so the update doesn't fit
+	qbt.SetFreeSpace(1 << 20)
+	for range 3 {
+		if err := b.checkWatch(*b.watches[w.key()]); err == nil {
+			t.Fatal("checkWatch added an update that doesn't fit")
+		}
+	}
+
+	sent := telegram.sent()
+	if len(sent) != 1 || sent[0].ChatID != 7 || !strings.Contains(sent[0].Text, "adding the new torrent failed") {
+		t.Fatalf("messages = %+v, want a single failure message to chat 7", sent)
+	}
+	if got := b.watches[w.key()].FailedHash; got != newHash {
+		t.Errorf("FailedHash = %q, want %q", got, newHash)
+	}
+	if _, ok := qbt.Torrent(newHash); ok {
+		t.Error("refused update was added")
+	}
+
+	// Once there is room the retry succeeds and clears the failure
+	qbt.SetFreeSpace(100 << 30)
+	if err := b.checkWatch(*b.watches[w.key()]); err != nil {
+		t.Fatalf("checkWatch retry: %v", err)
+	}
+	if current := b.watches[w.key()]; current.InfoHash != newHash || current.FailedHash != "" {
+		t.Errorf("watch after the retry = %+v, want the new hash and no failure", current)
+	}
+}
+
+func TestHandleUnwatch(t *testing.T) {
+	b, telegram, _ := newTestBot(t, nil)
+	w := &watchedTopic{Tracker: "rutor", ID: "123", Title: "Series", InfoHash: strings.Repeat("a", 40), SavePath: "/tv", ChatID: 7}
+	b.watches[w.key()] = w
+
+	// Another chat can't stop watching the topic
+	b.handleUnwatch(8, 1, w.key())
+	if _, ok := b.watches[w.key()]; !ok {
+		t.Fatal("another chat stopped watching the topic")
+	}
+	if sent := telegram.sent(); len(sent) != 1 || sent[0].ChatID != 8 || !strings.HasPrefix(sent[0].Text, "❌") {
+		t.Errorf("messages = %+v, want an error for chat 8", sent)
+	}
+
+	// The chat that watches it can
+	b.handleUnwatch(7, 1, w.key())
+	if _, ok := b.watches[w.key()]; ok {
+		t.Error("the watching chat couldn't stop watching the topic")
+	}
+	if sent := telegram.sent(); len(sent) != 2 || sent[1].Method != "editMessageText" || !strings.Contains(sent[1].Text, "Stopped watching Series") {
+		t.Errorf("messages = %+v, want the watch list updated", sent)
+	}
+
+	// And so can an admin
+	b.watches[w.key()] = w
+	b.handleUnwatch(100, 1, w.key())
+	if _, ok := b.watches[w.key()]; ok {
+		t.Error("an admin couldn't stop watching the topic")
+	}
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"telegramBot/internal/utils"
+)
+
+const (
+	// maxDirectTorrentSize caps the size of .torrent files fetched from arbitrary URLs
+	maxDirectTorrentSize = 10 << 20
+	// maxDirectRedirects caps the number of redirects followed for a direct link
+	maxDirectRedirects = 5
+)
+
+// torrentContentTypes are the content types servers use for .torrent files
+var torrentContentTypes = map[string]bool{
+	"application/x-bittorrent": true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+}
+
+// errPrivateAddress is returned when a direct link resolves to an address
+// that is not on the public internet
+var errPrivateAddress = errors.New("refusing to connect to a private address")
+
+// nonPublicPrefixes are special-purpose ranges that the netip predicates
+// don't cover: carrier-grade NAT, benchmarking, documentation, reserved and
+// local-use NAT64 addresses
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// nat64Prefix and sixToFourPrefix are IPv6 ranges that carry an IPv4
+// address, which must be public as well
+var (
+	nat64Prefix     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+// checkPublicAddress refuses connections to loopback, private, link-local
+// and other non-public addresses. It runs on the resolved address of every
+// connection, so redirects and DNS names pointing inside the network are
+// caught too.
+func checkPublicAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errPrivateAddress, address)
+	}
+
+	if !isPublicAddress(addrPort.Addr().Unmap()) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// isPublicAddress reports whether ip is a global unicast address outside
+// the private and special-purpose ranges
+func isPublicAddress(ip netip.Addr) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+
+	// Translated addresses lead to the IPv4 address inside them
+	raw := ip.As16()
+	switch {
+	case nat64Prefix.Contains(ip):
+		return isPublicAddress(netip.AddrFrom4([4]byte(raw[12:16])))
+	case sixToFourPrefix.Contains(ip):
+		return isPublicAddress(netip.AddrFrom4([4]byte(raw[2:6])))
+	}
+	return true
+}
+
+// newDirectClient creates the HTTP client used for direct .torrent links.
+// It does not share cookies with the trackers and limits redirects. Links
+// come from chat messages, so unless allowPrivate is set only public
+// addresses can be reached, and environment proxies are ignored so the
+// check applies to the actual target.
+func newDirectClient(allowPrivate bool) http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = checkPublicAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxDirectRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxDirectRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme: %s", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// DownloadDirect fetches a .torrent file from an arbitrary http(s) URL. The
+// response is accepted only if it is small enough and parses as a torrent.
+func (t *TorrentTrackerClient) DownloadDirect(link string) ([]byte, error) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid link: %s", link)
+	}
+
+	resp, err := t.direct.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status code: %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > maxDirectTorrentSize {
+		return nil, fmt.Errorf("file is too large to be a torrent (%d bytes)", resp.ContentLength)
+	}
+
+	// Skip obvious web pages without reading them
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "" && !torrentContentTypes[mediaType] && mediaType != "text/plain" {
+		return nil, fmt.Errorf("link is not a torrent file (%s)", mediaType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDirectTorrentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(body) > maxDirectTorrentSize {
+		return nil, errors.New("file is too large to be a torrent")
+	}
+
+	// Sniff the content, servers often send torrents with a generic type
+	if len(body) == 0 || body[0] != 'd' {
+		return nil, errors.New("link is not a torrent file")
+	}
+	if _, err := utils.ParseTorrentMeta(body); err != nil {
+		return nil, fmt.Errorf("link is not a valid torrent file: %w", err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"telegramBot/internal/client/qbtfake"
+)
+
+// newDirectTestClient returns a tracker client whose direct downloads may
+// reach the loopback httptest servers
+func newDirectTestClient(t *testing.T) *TorrentTrackerClient {
+	t.Helper()
+	c := newLinkClient(t)
+	c.direct = newDirectClient(true)
+	return c
+}
+
+func TestDownloadDirectRefusesPrivateAddresses(t *testing.T) {
+	torrent := qbtfake.MakeTorrent("private", 1<<20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(torrent)
+	}))
+	defer server.Close()
+
+	c := newLinkClient(t)
+	for _, link := range []string{
+		server.URL + "/file.torrent",
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/file.torrent",
+	} {
+		if _, err := c.DownloadDirect(link); !errors.Is(err, errPrivateAddress) {
+			t.Errorf("DownloadDirect(%s) error = %v, want errPrivateAddress", link, err)
+		}
+	}
+}
+
+func TestCheckPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.0.0.5:8080", false},
+		{"172.16.1.1:80", false},
+		{"192.168.1.1:8080", false},
+		{"169.254.169.254:80", false},
+		{"0.0.0.0:80", false},
+		{"[fd00::1]:80", false},
+		{"[fe80::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"224.0.0.1:80", false},
+		{"255.255.255.255:80", false},
+		{"100.64.0.1:80", false},
+		{"100.127.255.254:80", false},
+		{"100.128.0.1:80", true},
+		{"0.1.2.3:80", false},
+		{"198.18.0.1:80", false},
+		{"198.19.255.255:80", false},
+		{"192.0.2.10:80", false},
+		{"[64:ff9b::7f00:1]:80", false},
+		{"[64:ff9b::a00:5]:80", false},
+		{"[64:ff9b::5db8:d822]:80", true},
+		{"[64:ff9b:1::1]:80", false},
+		{"[2002:c0a8:101::1]:80", false},
+		{"[2002:5db8:d822::1]:80", true},
+		{"[2001:db8::1]:80", false},
+		{"[ff02::1]:80", false},
+	}
+
+	for _, tt := range tests {
+		err := checkPublicAddress("tcp", tt.address, nil)
+		if public := err == nil; public != tt.public {
+			t.Errorf("checkPublicAddress(%s) = %v, want public %v", tt.address, err, tt.public)
+		}
+	}
+}
+
+func TestDownloadDirect(t *testing.T) {
+	torrent := qbtfake.MakeTorrent("direct", 1<<20)
+	huge := append(append([]byte{}, torrent...), bytes.Repeat([]byte{0}, maxDirectTorrentSize)...)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		chunked     bool
+		wantErr     string
+	}{
+		{"torrent", "application/x-bittorrent", torrent, false, ""},
+		{"generic type", "application/octet-stream", torrent, false, ""},
+		{"no type", "", torrent, false, ""},
+		{"web page", "text/html; charset=utf-8", []byte("<html></html>"), false, "not a torrent file (text/html)"},
+		{"web page sent as binary", "application/octet-stream", []byte("<html></html>"), false, "not a torrent file"},
+		{"broken torrent", "application/x-bittorrent", []byte("d8:announce"), false, "not a valid torrent file"},
+		{"too large", "application/x-bittorrent", huge, false, "too large"},
+		{"too large without length", "application/x-bittorrent", huge, true, "too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Without a type the server would sniff one from the body
+				w.Header()["Content-Type"] = []string{tt.contentType}
+				if tt.chunked {
+					w.(http.Flusher).Flush()
+				}
+				w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			body, err := newDirectTestClient(t).DownloadDirect(server.URL + "/file.torrent")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DownloadDirect: %v", err)
+			}
+			if !bytes.Equal(body, tt.body) {
+				t.Error("DownloadDirect returned a different file")
+			}
+		})
+	}
+}
+
+func TestDownloadDirectRedirects(t *testing.T) {
+	torrent := qbtfake.MakeTorrent("redirected", 1<<20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/ftp":
+			http.Redirect(w, r, "ftp://example.com/file.torrent", http.StatusFound)
+		case "/short":
+			http.Redirect(w, r, "/file.torrent", http.StatusMovedPermanently)
+		default:
+			w.Write(torrent)
+		}
+	}))
+	defer server.Close()
+
+	c := newDirectTestClient(t)
+	if body, err := c.DownloadDirect(server.URL + "/short"); err != nil || !bytes.Equal(body, torrent) {
+		t.Errorf("DownloadDirect after a redirect = %d bytes, %v", len(body), err)
+	}
+	if _, err := c.DownloadDirect(server.URL + "/loop"); err == nil || !strings.Contains(err.Error(), "redirects") {
+		t.Errorf("DownloadDirect with a redirect loop error = %v, want the redirect limit", err)
+	}
+	if _, err := c.DownloadDirect(server.URL + "/ftp"); err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Errorf("DownloadDirect redirected to ftp error = %v, want an unsupported scheme", err)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import "errors"
+
+var (
+	// ErrBackendUnavailable is returned when qBittorrent can't be reached,
+	// even after retrying, or the circuit breaker is open
+	ErrBackendUnavailable = errors.New("qBittorrent is unavailable")
+
+	// ErrNotFound is returned when the requested torrent or resource doesn't exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized is returned when qBittorrent rejects the credentials
+	ErrUnauthorized = errors.New("qBittorrent rejected the credentials")
+)
+
+// Tracker login failures, wrapped in a *LoginError
+var (
+	// ErrBadCredentials is returned when a tracker rejects the username or password
+	ErrBadCredentials = errors.New("wrong username or password")
+
+	// ErrCaptchaRequired is returned when a tracker asks for a captcha
+	ErrCaptchaRequired = errors.New("captcha required")
+
+	// ErrBanned is returned when the tracker account is banned or disabled
+	ErrBanned = errors.New("account is banned")
+
+	// ErrSiteDown is returned when the tracker can't be reached or fails to respond
+	ErrSiteDown = errors.New("tracker is unavailable")
+)
+
+// Topic states that make a tracker link unusable
+var (
+	// ErrTopicClosed is returned when the tracker topic is closed
+	ErrTopicClosed = errors.New("topic is closed")
+
+	// ErrTopicAbsorbed is returned when the topic was absorbed by another topic
+	ErrTopicAbsorbed = errors.New("topic was absorbed by another topic")
+)
+
+// ErrUnsupportedLink is returned when a tracker link points to a page
+// without a torrent, such as a forum index or search results
+var ErrUnsupportedLink = errors.New("link does not point to a torrent")
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"bytes"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"telegramBot/internal/utils"
+)
+
+var (
+	// tagRegex matches HTML tags
+	tagRegex = regexp.MustCompile(`<[^>]*>`)
+	// spaceRegex matches runs of whitespace
+	spaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// decodePage returns the text of an HTML page, converting it from
+// Windows-1251 when the response or the page says so
+func decodePage(resp *http.Response, body []byte) string {
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	head := bytes.ToLower(body[:min(len(body), 1024)])
+
+	if strings.Contains(contentType, "1251") || bytes.Contains(head, []byte("windows-1251")) {
+		return utils.DecodeWindows1251(body)
+	}
+	return string(body)
+}
+
+// cleanText strips tags and entities from an HTML fragment and collapses whitespace
+func cleanText(fragment string) string {
+	text := tagRegex.ReplaceAllString(fragment, "")
+	text = strings.ReplaceAll(html.UnescapeString(text), "\u00a0", " ")
+	return strings.TrimSpace(spaceRegex.ReplaceAllString(text, " "))
+}
+
+// resolveURL resolves a possibly relative link against the page it was found on
+func resolveURL(pageURL, link string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return link
+	}
+	ref, err := base.Parse(link)
+	if err != nil {
+		return link
+	}
+	return ref.String()
+}
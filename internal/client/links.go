@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// linkRegex finds http(s) links in free text
+var linkRegex = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// linkTrailingPunctuation is punctuation that ends a sentence rather than a link
+const linkTrailingPunctuation = ".,;:!?)]}»"
+
+// FindLinks returns all http(s) links in a piece of text, without the
+// punctuation of the sentence around them
+func FindLinks(text string) []string {
+	links := linkRegex.FindAllString(text, -1)
+	for i, link := range links {
+		links[i] = strings.TrimRight(link, linkTrailingPunctuation)
+	}
+	return links
+}
+
+// linkHost returns the host of a link in lower case, without a trailing dot
+func linkHost(u *url.URL) string {
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// linkPath returns the non-empty segments of a link's path, so that
+// /forum/viewtopic.php and //forum/viewtopic.php/ both give
+// ["forum", "viewtopic.php"]
+func linkPath(u *url.URL) []string {
+	var segments []string
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// linkPage returns the last path segment of a link in lower case, or "" for the site root
+func linkPage(u *url.URL) string {
+	segments := linkPath(u)
+	if len(segments) == 0 {
+		return ""
+	}
+	return strings.ToLower(segments[len(segments)-1])
+}
+
+// unsupportedLink explains which kind of page a tracker link points to
+func unsupportedLink(t Tracker, page string) error {
+	return fmt.Errorf("%w: this %s link points to %s, send a link to a torrent page instead", ErrUnsupportedLink, t.DisplayName(), page)
+}
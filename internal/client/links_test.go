@@ -0,0 +1,229 @@
+package client
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newLinkClient returns a tracker client for parsing links only
+func newLinkClient(t testing.TB) *TorrentTrackerClient {
+	t.Helper()
+	c, err := NewTorrentTrackerClient(nil, nil)
+	if err != nil {
+		t.Fatalf("NewTorrentTrackerClient: %v", err)
+	}
+	return c
+}
+
+// linkTests are tracker links with the topic they point to. An empty ID
+// means the link belongs to the tracker but must be refused.
+var linkTests = []struct {
+	name    string
+	link    string
+	tracker string
+	id      string
+}{
+	// rutracker topic pages
+	{"rutracker topic", "https://rutracker.org/forum/viewtopic.php?t=6543210", "rutracker", "6543210"},
+	{"rutracker topic over http", "http://rutracker.org/forum/viewtopic.php?t=6543210", "rutracker", "6543210"},
+	{"rutracker topic page 2", "https://rutracker.org/forum/viewtopic.php?t=6543210&start=30", "rutracker", "6543210"},
+	{"rutracker topic with anchor", "https://rutracker.org/forum/viewtopic.php?t=6543210#seeders", "rutracker", "6543210"},
+	{"rutracker topic on a mirror", "https://rutracker.net/forum/viewtopic.php?t=6543210", "rutracker", "6543210"},
+	{"rutracker topic with www", "https://www.rutracker.org/forum/viewtopic.php?t=6543210", "rutracker", "6543210"},
+	{"rutracker topic in upper case", "HTTPS://RuTracker.ORG/forum/viewtopic.php?t=6543210", "rutracker", "6543210"},
+	{"rutracker topic with trailing dot host", "https://rutracker.org./forum/viewtopic.php?t=6543210", "rutracker", "6543210"},
+	{"rutracker topic with html escaped query", "https://rutracker.org/forum/viewtopic.php?t=6543210&amp;start=30", "rutracker", "6543210"},
+	{"rutracker mobile topic", "https://m.rutracker.org/forum/viewtopic.php?t=6543210", "rutracker", "6543210"},
+
+	// rutracker downloads and short links
+	{"rutracker download", "https://rutracker.org/forum/dl.php?t=6543210", "rutracker", "6543210"},
+	{"rutracker short link", "https://rutracker.org/t/6543210", "rutracker", "6543210"},
+	{"rutracker short link with slash", "https://rutracker.org/forum/t/6543210/", "rutracker", "6543210"},
+
+	// rutracker pages without a torrent
+	{"rutracker post", "https://rutracker.org/forum/viewtopic.php?p=87654321#87654321", "rutracker", ""},
+	{"rutracker topic without id", "https://rutracker.org/forum/viewtopic.php", "rutracker", ""},
+	{"rutracker topic with bad id", "https://rutracker.org/forum/viewtopic.php?t=abc", "rutracker", ""},
+	{"rutracker forum section", "https://rutracker.org/forum/viewforum.php?f=252", "rutracker", ""},
+	{"rutracker search", "https://rutracker.org/forum/tracker.php?nm=ubuntu", "rutracker", ""},
+	{"rutracker profile", "https://rutracker.org/forum/profile.php?mode=viewprofile&u=1", "rutracker", ""},
+	{"rutracker index", "https://rutracker.org/forum/index.php", "rutracker", ""},
+	{"rutracker root", "https://rutracker.org/", "rutracker", ""},
+	{"rutracker short link without id", "https://rutracker.org/t/", "rutracker", ""},
+
+	// kinozal torrent pages and downloads
+	{"kinozal details", "https://kinozal.tv/details.php?id=1987654", "kinozal", "1987654"},
+	{"kinozal details with extra query", "https://kinozal.tv/details.php?s=ubuntu&id=1987654", "kinozal", "1987654"},
+	{"kinozal mirror", "https://kinozal.me/details.php?id=1987654", "kinozal", "1987654"},
+	{"kinozal mobile details", "https://m.kinozal.tv/details.php?id=1987654", "kinozal", "1987654"},
+	{"kinozal comments", "https://kinozal.tv/comment.php?id=1987654", "kinozal", "1987654"},
+	{"kinozal file list", "https://kinozal.tv/get_srv_details.php?id=1987654&action=2", "kinozal", "1987654"},
+	{"kinozal download", "https://dl.kinozal.tv/download.php?id=1987654", "kinozal", "1987654"},
+
+	// kinozal pages without a torrent
+	{"kinozal details without id", "https://kinozal.tv/details.php", "kinozal", ""},
+	{"kinozal browse", "https://kinozal.tv/browse.php?s=ubuntu", "kinozal", ""},
+	{"kinozal user", "https://kinozal.tv/userdetails.php?id=1", "kinozal", ""},
+	{"kinozal forum", "https://kinozal.tv/forum/showthread.php?t=1", "kinozal", ""},
+	{"kinozal home", "https://kinozal.tv", "kinozal", ""},
+
+	// nnmclub topic pages
+	{"nnmclub topic", "https://nnmclub.to/forum/viewtopic.php?t=1234567", "nnmclub", "1234567"},
+	{"nnmclub topic page 2", "https://nnmclub.to/forum/viewtopic.php?t=1234567&start=20", "nnmclub", "1234567"},
+	{"nnmclub old domain", "https://nnm-club.me/forum/viewtopic.php?t=1234567", "nnmclub", "1234567"},
+	{"nnmclub topic with www", "https://www.nnmclub.to/forum/viewtopic.php?t=1234567", "nnmclub", "1234567"},
+
+	// nnmclub pages without a topic
+	{"nnmclub post", "https://nnmclub.to/forum/viewtopic.php?p=98765432#98765432", "nnmclub", ""},
+	{"nnmclub download", "https://nnmclub.to/forum/download.php?id=1234567", "nnmclub", ""},
+	{"nnmclub forum section", "https://nnmclub.to/forum/viewforum.php?f=24", "nnmclub", ""},
+	{"nnmclub search", "https://nnmclub.to/forum/tracker.php?nm=ubuntu", "nnmclub", ""},
+	{"nnmclub profile", "https://nnmclub.to/forum/profile.php?mode=viewprofile&u=1", "nnmclub", ""},
+	{"nnmclub index", "https://nnmclub.to/forum/", "nnmclub", ""},
+	{"nnmclub portal with t", "https://nnmclub.to/forum/portal.php?t=1234567", "nnmclub", ""},
+
+	// rutor torrent pages and downloads
+	{"rutor torrent", "https://rutor.info/torrent/987654", "rutor", "987654"},
+	{"rutor torrent with name", "https://rutor.info/torrent/987654/ubuntu-24.04-lts", "rutor", "987654"},
+	{"rutor mirror", "http://rutor.is/torrent/987654/ubuntu", "rutor", "987654"},
+	{"rutor download", "https://d.rutor.info/download/987654", "rutor", "987654"},
+	{"rutor torrent in upper case", "https://RUTOR.INFO/Torrent/987654", "rutor", "987654"},
+
+	// rutor pages without a torrent
+	{"rutor torrent without id", "https://rutor.info/torrent/", "rutor", ""},
+	{"rutor torrent with bad id", "https://rutor.info/torrent/ubuntu", "rutor", ""},
+	{"rutor search", "https://rutor.info/search/0/0/000/0/ubuntu", "rutor", ""},
+	{"rutor browse", "https://rutor.info/browse/0/1/0/0", "rutor", ""},
+	{"rutor top", "https://rutor.info/top", "rutor", ""},
+	{"rutor category", "https://rutor.info/kino", "rutor", ""},
+	{"rutor home", "https://rutor.info/", "rutor", ""},
+}
+
+func TestParseLink(t *testing.T) {
+	c := newLinkClient(t)
+
+	for _, tt := range linkTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, id, err := c.ParseLink(tt.link)
+			if tt.id == "" {
+				if !errors.Is(err, ErrUnsupportedLink) {
+					t.Fatalf("ParseLink(%q) error = %v, want ErrUnsupportedLink", tt.link, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLink(%q): %v", tt.link, err)
+			}
+			if tracker != tt.tracker || id != tt.id {
+				t.Errorf("ParseLink(%q) = %s %s, want %s %s", tt.link, tracker, id, tt.tracker, tt.id)
+			}
+		})
+	}
+}
+
+func TestParseLinkErrorNamesPage(t *testing.T) {
+	c := newLinkClient(t)
+
+	_, _, err := c.ParseLink("https://rutracker.org/forum/viewforum.php?f=252")
+	if err == nil || !strings.Contains(err.Error(), "forum section") {
+		t.Errorf("error = %v, want it to mention the forum section", err)
+	}
+}
+
+func TestParseLinkOtherSites(t *testing.T) {
+	c := newLinkClient(t)
+
+	for _, link := range []string{
+		"https://example.com/forum/viewtopic.php?t=1",
+		"https://notrutracker.org/forum/viewtopic.php?t=1",
+		"https://rutracker.org.example.com/forum/viewtopic.php?t=1",
+		"ftp://rutracker.org/forum/viewtopic.php?t=1",
+		"rutracker.org/forum/viewtopic.php?t=1",
+	} {
+		if _, _, err := c.ParseLink(link); err == nil || errors.Is(err, ErrUnsupportedLink) {
+			t.Errorf("ParseLink(%q) error = %v, want an unknown tracker link", link, err)
+		}
+	}
+}
+
+func TestFindLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"none", "no links here", nil},
+		{"single", "https://rutracker.org/forum/viewtopic.php?t=1", []string{"https://rutracker.org/forum/viewtopic.php?t=1"}},
+		{"end of sentence", "Look at https://kinozal.tv/details.php?id=2.", []string{"https://kinozal.tv/details.php?id=2"}},
+		{"in parentheses", "(see https://kinozal.tv/details.php?id=2)", []string{"https://kinozal.tv/details.php?id=2"}},
+		{"in quotes", `"https://kinozal.tv/details.php?id=2"`, []string{"https://kinozal.tv/details.php?id=2"}},
+		{"several", "https://a.test/1, https://b.test/2\nhttp://c.test/3", []string{"https://a.test/1", "https://b.test/2", "http://c.test/3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindLinks(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindLinks(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzParseLink(f *testing.F) {
+	for _, tt := range linkTests {
+		f.Add(tt.link)
+	}
+	c := newLinkClient(f)
+
+	f.Fuzz(func(t *testing.T, link string) {
+		tracker, id, err := c.ParseLink(link)
+		if err != nil {
+			return
+		}
+
+		// Accepted links name a known tracker and a numeric topic
+		if _, ok := c.trackers[tracker]; !ok {
+			t.Fatalf("ParseLink(%q) returned unknown tracker %q", link, tracker)
+		}
+		if !isNumeric(id) {
+			t.Fatalf("ParseLink(%q) returned non-numeric ID %q", link, id)
+		}
+
+		// The ID survives being put back into a canonical link
+		canonical := map[string]string{
+			"rutracker": "https://rutracker.org/forum/viewtopic.php?t=",
+			"kinozal":   "https://kinozal.tv/details.php?id=",
+			"nnmclub":   "https://nnmclub.to/forum/viewtopic.php?t=",
+			"rutor":     "https://rutor.info/torrent/",
+		}[tracker]
+		if canonical == "" {
+			return
+		}
+		if again, againID, err := c.ParseLink(canonical + url.QueryEscape(id)); err != nil || again != tracker || againID != id {
+			t.Fatalf("ParseLink(%q) = %s %s, but its canonical link gives %s %s (%v)", link, tracker, id, again, againID, err)
+		}
+	})
+}
+
+func FuzzFindLinks(f *testing.F) {
+	f.Add("see https://rutracker.org/forum/viewtopic.php?t=1.")
+	f.Add("https://kinozal.tv/details.php?id=2 and (http://rutor.info/torrent/3)")
+	f.Add("no links")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		for _, link := range FindLinks(text) {
+			if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+				t.Fatalf("FindLinks(%q) returned %q without a scheme", text, link)
+			}
+			if !strings.Contains(text, link) {
+				t.Fatalf("FindLinks(%q) returned %q, which is not in the text", text, link)
+			}
+			if strings.ContainsAny(link, " \t\n<>\"'") {
+				t.Fatalf("FindLinks(%q) returned %q with a separator in it", text, link)
+			}
+		}
+	})
+}
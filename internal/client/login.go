@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// LoginError is a failed tracker login. Kind is one of the tracker login
+// errors and Message is the tracker's own explanation, when it gave one.
+type LoginError struct {
+	Tracker string
+	Kind    error
+	Message string
+}
+
+// Error implements error
+func (e *LoginError) Error() string {
+	text := fmt.Sprintf("%s login failed", e.Tracker)
+	if e.Kind != nil {
+		text += ": " + e.Kind.Error()
+	}
+	if e.Message != "" {
+		text += fmt.Sprintf(" (%s)", e.Message)
+	}
+	return text
+}
+
+// Unwrap returns the kind of login failure
+func (e *LoginError) Unwrap() error {
+	return e.Kind
+}
+
+// loginCheck describes how a tracker shows the result of a login attempt
+type loginCheck struct {
+	SessionCookie  string         // Cookie the tracker sets for a logged in session
+	ProfileMarker  string         // Text only shown to logged in users
+	BadCredentials []string       // Lower case error texts for a wrong username or password
+	Banned         []string       // Lower case error texts for a banned account
+	MessageRegex   *regexp.Regexp // Captures the error message shown on the page
+}
+
+// verify inspects the page returned by a login form and returns a
+// *LoginError unless it shows a successful login. Failure phrases are only
+// looked for in the tracker's error message, since ordinary pages can
+// contain them in posts and news.
+func (lc loginCheck) verify(c *http.Client, t Tracker, loginURL, page string) error {
+	if lc.ProfileMarker != "" && strings.Contains(page, lc.ProfileMarker) {
+		return nil
+	}
+	if lc.SessionCookie != "" && c.Jar != nil {
+		if u, err := url.Parse(loginURL); err == nil {
+			for _, cookie := range c.Jar.Cookies(u) {
+				if cookie.Name == lc.SessionCookie {
+					return nil
+				}
+			}
+		}
+	}
+
+	var message string
+	if lc.MessageRegex != nil {
+		if m := lc.MessageRegex.FindStringSubmatch(page); m != nil {
+			message = cleanText(m[1])
+		}
+	}
+	lower := strings.ToLower(message)
+
+	for _, phrase := range lc.Banned {
+		if strings.Contains(lower, phrase) {
+			return &LoginError{Tracker: t.DisplayName(), Kind: ErrBanned, Message: message}
+		}
+	}
+	for _, phrase := range lc.BadCredentials {
+		if strings.Contains(lower, phrase) {
+			return &LoginError{Tracker: t.DisplayName(), Kind: ErrBadCredentials, Message: message}
+		}
+	}
+
+	if message == "" {
+		message = "the tracker did not confirm the login"
+	}
+	return &LoginError{Tracker: t.DisplayName(), Message: message}
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"telegramBot/internal/models"
+)
+
+// mirrorRole is one of the hosts a tracker uses, such as its site or its
+// download server, with the base URLs to use instead of the default one
+type mirrorRole struct {
+	def   *url.URL   // Default base URL the tracker's requests use
+	bases []*url.URL // Base URLs in order of preference, ending with the default
+
+	mu      sync.Mutex
+	current int // Index of the base URL that worked last
+}
+
+// isDefault reports whether base is the role's default base URL
+func (r *mirrorRole) isDefault(base *url.URL) bool {
+	return strings.EqualFold(base.Host, r.def.Host) && base.Path == r.def.Path
+}
+
+// mirrorTransport sends requests for a tracker's hosts to the configured
+// base URLs, moving on to the next one when a mirror can't be reached.
+// Each role is mapped on its own, so dl.kinozal.tv goes to the download
+// mirrors whatever the site mirror is, and a mirror's path is kept.
+type mirrorTransport struct {
+	base   http.RoundTripper
+	domain string // Domain the tracker's cookies are kept for
+	roles  []*mirrorRole
+}
+
+// newMirrorRole builds a role from its default base URL, the preferred base
+// URL and the fallback mirrors. It returns nil if no mirror is configured.
+func newMirrorRole(def, preferred string, mirrors []string) (*mirrorRole, error) {
+	if def == "" || (preferred == "" && len(mirrors) == 0) {
+		return nil, nil
+	}
+
+	defURL, err := url.Parse(def)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default base URL: %q", def)
+	}
+	role := &mirrorRole{def: defURL}
+
+	seen := make(map[string]bool)
+	for _, base := range append(append([]string{preferred}, mirrors...), def) {
+		base = strings.TrimSuffix(base, "/")
+		if base == "" || seen[base] {
+			continue
+		}
+		seen[base] = true
+
+		u, err := url.Parse(base)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid mirror URL: %q", base)
+		}
+		role.bases = append(role.bases, u)
+	}
+	return role, nil
+}
+
+// newMirrorTransport wraps base to send the requests for a tracker's site and
+// download hosts to the mirrors configured in creds. The default base URL is
+// always kept as the last fallback.
+func newMirrorTransport(base http.RoundTripper, domain string, hosts TrackerHosts, creds models.TrackerCredentials) (http.RoundTripper, error) {
+	t := &mirrorTransport{base: base, domain: domain}
+
+	if hosts.Download == "" && (creds.DownloadURL != "" || len(creds.DownloadMirrors) > 0) {
+		return nil, fmt.Errorf("downloads are served by the site, configure site mirrors instead")
+	}
+
+	site, err := newMirrorRole(hosts.Site, creds.SiteURL, creds.Mirrors)
+	if err != nil {
+		return nil, err
+	}
+	download, err := newMirrorRole(hosts.Download, creds.DownloadURL, creds.DownloadMirrors)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range []*mirrorRole{site, download} {
+		if role != nil {
+			t.roles = append(t.roles, role)
+		}
+	}
+
+	if len(t.roles) == 0 {
+		return base, nil
+	}
+	return t, nil
+}
+
+// role returns the role whose default base URL the request is for
+func (t *mirrorTransport) role(u *url.URL) *mirrorRole {
+	for _, role := range t.roles {
+		if strings.EqualFold(u.Host, role.def.Host) && strings.HasPrefix(u.Path, role.def.Path) {
+			return role
+		}
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	role := t.role(req.URL)
+	if role == nil {
+		return t.base.RoundTrip(req)
+	}
+	path := strings.TrimPrefix(req.URL.Path, role.def.Path)
+
+	role.mu.Lock()
+	start := role.current
+	role.mu.Unlock()
+
+	var lastErr error
+	for i := range role.bases {
+		index := (start + i) % len(role.bases)
+		mirror := role.bases[index]
+
+		// Requests must not be modified, so work on a copy
+		attempt := req.Clone(req.Context())
+		attempt.URL.Scheme = mirror.Scheme
+		attempt.URL.Host = mirror.Host
+		attempt.URL.Path = mirror.Path + path
+		attempt.URL.RawPath = ""
+		attempt.Host = ""
+		if i > 0 && req.Body != nil {
+			// The body was consumed by the previous attempt
+			if req.GetBody == nil {
+				break
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				break
+			}
+			attempt.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attempt)
+		if err != nil {
+			// Connection failures move on to the next mirror
+			lastErr = err
+			if req.Context().Err() != nil {
+				break
+			}
+			continue
+		}
+
+		role.mu.Lock()
+		role.current = index
+		role.mu.Unlock()
+
+		if !role.isDefault(mirror) {
+			t.unmirrorResponse(resp, role, mirror)
+		}
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("all mirrors of %s failed: %w", role.def.Host, lastErr)
+}
+
+// unmirrorResponse makes a mirror's response look like it came from the
+// default host, since the client and its cookie jar only see the original
+// URLs. Cookies move to the tracker's domain and redirects to the mirror
+// point back to the default base URL.
+func (t *mirrorTransport) unmirrorResponse(resp *http.Response, role *mirrorRole, mirror *url.URL) {
+	if location := resp.Header.Get("Location"); location != "" {
+		if u, err := mirror.Parse(location); err == nil && strings.EqualFold(u.Host, mirror.Host) && strings.HasPrefix(u.Path, mirror.Path) {
+			u.Scheme = role.def.Scheme
+			u.Host = role.def.Host
+			u.Path = role.def.Path + strings.TrimPrefix(u.Path, mirror.Path)
+			u.RawPath = ""
+			resp.Header.Set("Location", u.String())
+		}
+	}
+
+	cookies := resp.Cookies()
+	if len(cookies) == 0 {
+		return
+	}
+
+	resp.Header.Del("Set-Cookie")
+	mirrorHost := strings.ToLower(mirror.Hostname())
+	for _, cookie := range cookies {
+		domain := strings.ToLower(strings.TrimPrefix(cookie.Domain, "."))
+		if domain == "" || mirrorHost == domain || strings.HasSuffix(mirrorHost, "."+domain) {
+			cookie.Domain = t.domain
+		}
+		if mirror.Path != "" && strings.HasPrefix(cookie.Path, mirror.Path) {
+			cookie.Path = "/" + strings.TrimLeft(strings.TrimPrefix(cookie.Path, mirror.Path), "/")
+		}
+		resp.Header.Add("Set-Cookie", cookie.String())
+	}
+}
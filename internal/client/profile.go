@@ -0,0 +1,126 @@
+package client
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
+)
+
+// hrefRegex captures the href attribute of a link tag
+var hrefRegex = regexp.MustCompile(`href=["']([^"']+)["']`)
+
+// profileFields lists where a tracker's profile page shows each statistic,
+// as regexes built by profileMarkers. Markers are tried in order.
+type profileFields struct {
+	Uploaded   []*regexp.Regexp
+	Downloaded []*regexp.Regexp
+	Ratio      []*regexp.Regexp
+	Bonus      []*regexp.Regexp
+}
+
+// profileMarkers compiles the regexes finding the value after each marker. A
+// marker is either the label in front of the value, such as "Ратио", or an
+// attribute of the element holding it, such as id="u_ratio".
+func profileMarkers(markers ...string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(markers))
+	for i, marker := range markers {
+		// Skip the rest of the marker's tag, a colon and any tags before the value
+		res[i] = regexp.MustCompile(regexp.QuoteMeta(marker) + `(?:[^<>]*>)?\s*:?\s*(?:<[^>]*>\s*)*([^<]+)`)
+	}
+	return res
+}
+
+// profileValue returns the text following the first marker found on the page
+func profileValue(page string, markers []*regexp.Regexp) string {
+	for _, re := range markers {
+		if m := re.FindStringSubmatch(page); m != nil {
+			if value := strings.Trim(cleanText(m[1]), ": "); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// parseProfileNumber parses a ratio or bonus that may use a decimal comma
+// or group digits with spaces
+func parseProfileNumber(value string) (float64, bool) {
+	value = strings.ReplaceAll(strings.ReplaceAll(value, " ", ""), ",", ".")
+	n, err := strconv.ParseFloat(value, 64)
+	return n, err == nil
+}
+
+// parseProfile reads the account statistics of a profile page. Statistics
+// missing from the page are left at zero, but the page must show the
+// transfer totals or the ratio.
+func parseProfile(page string, fields profileFields) (*models.TrackerProfile, error) {
+	profile := &models.TrackerProfile{}
+	found := false
+
+	if value := profileValue(page, fields.Uploaded); value != "" {
+		if size, err := utils.ParseSize(value); err == nil {
+			profile.Uploaded, found = size, true
+		}
+	}
+	if value := profileValue(page, fields.Downloaded); value != "" {
+		if size, err := utils.ParseSize(value); err == nil {
+			profile.Downloaded, found = size, true
+		}
+	}
+	if ratio, ok := parseProfileNumber(profileValue(page, fields.Ratio)); ok {
+		profile.Ratio, found = ratio, true
+	} else if profile.Downloaded > 0 {
+		profile.Ratio = float64(profile.Uploaded) / float64(profile.Downloaded)
+	}
+	if bonus, ok := parseProfileNumber(profileValue(page, fields.Bonus)); ok {
+		profile.Bonus = bonus
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no account statistics on the profile page")
+	}
+	return profile, nil
+}
+
+// fetchForumProfile opens a forum page, follows the logged-in user's profile
+// link found by linkRegex, whose first group is the link tag, and parses the
+// profile page
+func fetchForumProfile(c *http.Client, t Tracker, pageURL string, linkRegex *regexp.Regexp, fields profileFields) (*models.TrackerProfile, error) {
+	page, err := fetchPage(c, t, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var profileURL string
+	if m := linkRegex.FindStringSubmatch(page); m != nil {
+		if href := hrefRegex.FindStringSubmatch(m[1]); href != nil {
+			profileURL = resolveURL(pageURL, html.UnescapeString(href[1]))
+		}
+	}
+	if profileURL == "" {
+		return nil, fmt.Errorf("profile link not found on %s", t.DisplayName())
+	}
+
+	return fetchProfile(c, t, profileURL, fields)
+}
+
+// fetchProfile fetches and parses a profile page
+func fetchProfile(c *http.Client, t Tracker, profileURL string, fields profileFields) (*models.TrackerProfile, error) {
+	page, err := fetchPage(c, t, profileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := parseProfile(page, fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s profile: %w", t.DisplayName(), err)
+	}
+	profile.Tracker = t.Name()
+	return profile, nil
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+// rutrackerProfilePage is the statistics table of a rutracker profile
+const rutrackerProfilePage = `<table class="ratio bCenter borderless">
+<tr><th>Всего:</th>
+<td id="u_up_total"><span class="editable bold">1.5 TB</span></td>
+<td id="u_down_total"><span class="editable bold">512 GB</span></td>
+</tr>
+<tr><th>Ратио</th><td id="u_ratio"><b class="gen">3.00</b></td></tr>
+<tr><th>Бонусы:</th><td><b>1 234,56</b></td></tr>
+</table>`
+
+// kinozalProfilePage is the statistics list of a kinozal profile
+const kinozalProfilePage = `<ul class="men w200">
+<li>Раздал<span class="floatright green">1,09 ТБ</span></li>
+<li>Скачал<span class="floatright red">745,2 ГБ</span></li>
+<li>Рейтинг<span class="floatright">1,5</span></li>
+<li>Бонусы<span class="floatright">50</span></li>
+</ul>`
+
+// nnmclubProfilePage is the statistics table of an nnmclub profile, which
+// shows no ratio
+const nnmclubProfilePage = `<table class="forumline">
+<tr><td class="genmed">Всего отдано:</td><td><b>200 GB</b></td></tr>
+<tr><td class="genmed">Всего скачано:</td><td><b>100 GB</b></td></tr>
+</table>`
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       string
+		fields     profileFields
+		uploaded   int64
+		downloaded int64
+		ratio      float64
+		bonus      float64
+		wantErr    bool
+	}{
+		{"rutracker", rutrackerProfilePage, rutrackerProfileFields, 3 << 39, 512 << 30, 3, 1234.56, false},
+		{"kinozal", kinozalProfilePage, kinozalProfileFields, 1198467674275, 800152407244, 1.5, 50, false},
+		{"nnmclub computes the ratio", nnmclubProfilePage, nnmclubProfileFields, 200 << 30, 100 << 30, 2, 0, false},
+		{"ratio only", `<td id="u_ratio">0.25</td>`, rutrackerProfileFields, 0, 0, 0.25, 0, false},
+		{"login page", `<form action="login.php"><input name="login_username"></form>`, rutrackerProfileFields, 0, 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := parseProfile(tt.page, tt.fields)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseProfile = %+v, want an error", profile)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseProfile: %v", err)
+			}
+			if profile.Uploaded != tt.uploaded || profile.Downloaded != tt.downloaded {
+				t.Errorf("transfers = %d up, %d down, want %d up, %d down", profile.Uploaded, profile.Downloaded, tt.uploaded, tt.downloaded)
+			}
+			if profile.Ratio != tt.ratio || profile.Bonus != tt.bonus {
+				t.Errorf("ratio %v bonus %v, want ratio %v bonus %v", profile.Ratio, profile.Bonus, tt.ratio, tt.bonus)
+			}
+		})
+	}
+}
+
+func TestProfileValue(t *testing.T) {
+	markers := profileMarkers("Ратио", `id="u_ratio"`)
+	tests := []struct {
+		page string
+		want string
+	}{
+		{`<b>Ратио:</b> 1.25`, "1.25"},
+		{`<th>Ратио</th><td><b class="gen">0.90</b></td>`, "0.90"},
+		{`<td id="u_ratio" class="bold">2.00</td>`, "2.00"},
+		{`<td id="u_ratio"><span>4.5</span></td>`, "4.5"},
+		{`<p>Рейтинг: 3</p>`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := profileValue(tt.page, markers); got != tt.want {
+			t.Errorf("profileValue(%q) = %q, want %q", tt.page, got, tt.want)
+		}
+	}
+
+	// Markers are text, not patterns
+	if got := profileValue("a.b: 1", profileMarkers("a+b")); got != "" {
+		t.Errorf("marker matched as a regex: %q", got)
+	}
+	if got := profileValue("<td>Отдал (*)</td><td>7 GB</td>", profileMarkers("Отдал (*)")); !strings.HasPrefix(got, "7") {
+		t.Errorf("marker with regex characters = %q, want 7 GB", got)
+	}
+}
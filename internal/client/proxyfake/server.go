@@ -0,0 +1,65 @@
+// Package proxyfake provides an in-process stand-in for an HTTP forward proxy
+// for tests. Instead of contacting the origin it answers proxied requests
+// with a handler, so tests can use made-up tracker hosts, and it records
+// every request it sees.
+package proxyfake
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Request is a request that went through the proxy
+type Request struct {
+	Host      string
+	Path      string
+	UserAgent string
+	ProxyAuth string // Value of the Proxy-Authorization header
+	Time      time.Time
+}
+
+// Server is a fake forward proxy backed by httptest.Server
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	origin   http.Handler
+	requests []Request
+}
+
+// New starts a proxy that serves every proxied request with origin
+func New(origin http.Handler) *Server {
+	s := &Server{origin: origin}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Requests returns the requests seen by the proxy in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// Proxied requests carry the absolute URL of the origin
+	if !r.URL.IsAbs() {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Host:      r.URL.Host,
+		Path:      r.URL.Path,
+		UserAgent: r.UserAgent(),
+		ProxyAuth: r.Header.Get("Proxy-Authorization"),
+		Time:      time.Now(),
+	})
+	s.mu.Unlock()
+
+	r.Header.Del("Proxy-Authorization")
+	s.origin.ServeHTTP(w, r)
+}
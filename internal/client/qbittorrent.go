@@ -3,23 +3,86 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
 )
 
-// QBittorrentClient handles communication with qBittorrent WebUI API
+// apiVersionExport is the first WebUI API version (qBittorrent 4.5) with torrents/export
+var apiVersionExport = APIVersion{Major: 2, Minor: 8, Patch: 14}
+
+// apiVersionStartStop is the first WebUI API version (qBittorrent 5.0) that
+// renamed pause/resume to stop/start
+var apiVersionStartStop = APIVersion{Major: 2, Minor: 11}
+
+// APIVersion is a parsed qBittorrent WebUI API version such as 2.11.2
+type APIVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseAPIVersion parses a version string returned by /api/v2/app/webapiVersion
+func ParseAPIVersion(version string) (APIVersion, error) {
+	var v APIVersion
+	parts := strings.Split(strings.TrimSpace(version), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, fmt.Errorf("invalid API version: %q", version)
+	}
+
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, fmt.Errorf("invalid API version: %q", version)
+		}
+		*fields[i] = n
+	}
+
+	return v, nil
+}
+
+// AtLeast reports whether v is the same as or newer than other
+func (v APIVersion) AtLeast(other APIVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+// String returns the version in dotted form
+func (v APIVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// QBittorrentClient handles communication with qBittorrent WebUI API.
+// Requests are retried with backoff, the session is renewed when qBittorrent
+// answers 403, and a circuit breaker stops calls while the backend is down.
 type QBittorrentClient struct {
-	client     http.Client
-	config     models.QBittorrentCredentials
+	// mu guards the fields below and serializes logins
+	mu         sync.Mutex
+	client     *http.Client
 	isLoggedIn bool
+	apiVersion APIVersion
+
+	config  models.QBittorrentCredentials
+	retry   retryPolicy
+	breaker *circuitBreaker
 }
 
 // NewQBittorrentClient creates a new qBittorrent client
@@ -29,19 +92,34 @@ func NewQBittorrentClient(config models.QBittorrentCredentials) (*QBittorrentCli
 		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
 	}
 
+	transport, err := newQBittorrentTransport(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure transport: %w", err)
+	}
+
 	client := &http.Client{
-		Jar:     jar,
-		Timeout: 30 * time.Second,
+		Jar:       jar,
+		Timeout:   30 * time.Second,
+		Transport: transport,
 	}
 
 	return &QBittorrentClient{
-		client: *client,
-		config: config,
+		client:  client,
+		config:  config,
+		retry:   defaultRetryPolicy,
+		breaker: newCircuitBreaker(5, time.Minute),
 	}, nil
 }
 
 // Login authenticates with qBittorrent WebUI
 func (q *QBittorrentClient) Login() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.login()
+}
+
+// login authenticates with qBittorrent WebUI; q.mu must be held
+func (q *QBittorrentClient) login() error {
 	loginURL := fmt.Sprintf("%s/api/v2/auth/login", q.config.URL)
 	data := url.Values{
 		"username": {q.config.Username},
@@ -59,53 +137,264 @@ func (q *QBittorrentClient) Login() error {
 		return fmt.Errorf("failed to read login response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Ok") {
-		return fmt.Errorf("login failed: %s", body)
+	// qBittorrent answers 403 when the IP is banned after failed logins
+	if resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: IP is banned after too many failed logins", ErrUnauthorized)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("login failed with status %d: %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(string(body), "Ok") {
+		return fmt.Errorf("%w: %s", ErrUnauthorized, body)
+	}
+
+	// Detect the API version so we know which endpoints to use. Without it
+	// the session is not usable, so the next request logs in again.
+	if err := q.detectAPIVersion(); err != nil {
+		return err
 	}
 
 	q.isLoggedIn = true
 	return nil
 }
 
-// ensureLoggedIn makes sure the client is authenticated
+// detectAPIVersion reads the WebUI API version of the connected qBittorrent; q.mu must be held
+func (q *QBittorrentClient) detectAPIVersion() error {
+	versionURL := fmt.Sprintf("%s/api/v2/app/webapiVersion", q.config.URL)
+	resp, err := q.client.Get(versionURL)
+	if err != nil {
+		return fmt.Errorf("API version request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read API version: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API version request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	version, err := ParseAPIVersion(string(body))
+	if err != nil {
+		return err
+	}
+
+	q.apiVersion = version
+	return nil
+}
+
+// APIVersion returns the WebUI API version detected at login
+func (q *QBittorrentClient) APIVersion() APIVersion {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.apiVersion
+}
+
+// usesStartStop reports whether the server uses the qBittorrent 5.x
+// stop/start endpoints and state names instead of pause/resume
+func (q *QBittorrentClient) usesStartStop() bool {
+	return q.APIVersion().AtLeast(apiVersionStartStop)
+}
+
+// torrentFilter translates a torrents/info filter to the name understood by
+// the connected qBittorrent version
+func (q *QBittorrentClient) torrentFilter(filter string) string {
+	if q.usesStartStop() {
+		switch filter {
+		case "paused":
+			return "stopped"
+		case "resumed":
+			return "running"
+		}
+		return filter
+	}
+
+	switch filter {
+	case "stopped":
+		return "paused"
+	case "running":
+		return "resumed"
+	}
+	return filter
+}
+
+// ensureLoggedIn makes sure the client is authenticated. An expired session
+// is detected by a 403 response in do, which resets the login state.
 func (q *QBittorrentClient) ensureLoggedIn() error {
-	// If we think we're logged in, test the connection
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	if q.isLoggedIn {
-		// Make a simple API call to verify the connection
-		testURL := fmt.Sprintf("%s/api/v2/app/version", q.config.URL)
-		resp, err := q.client.Get(testURL)
-
-		// If the request succeeds and returns 200 OK, we're still logged in
-		if err == nil && resp.StatusCode == http.StatusOK {
-			resp.Body.Close()
-			return nil
+		return nil
+	}
+	return q.login()
+}
+
+// httpClient returns the current HTTP client
+func (q *QBittorrentClient) httpClient() *http.Client {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.client
+}
+
+// markLoggedOut forgets the current session so the next request logs in again
+func (q *QBittorrentClient) markLoggedOut() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.isLoggedIn = false
+}
+
+// send performs a single API request and returns the status code and body
+func (q *QBittorrentClient) send(method, endpoint string, body []byte, contentType string) (int, []byte, error) {
+	req, err := http.NewRequest(method, q.config.URL+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", "TelegramTorrentBot")
+
+	resp, err := q.httpClient().Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, respBody, nil
+}
+
+// idempotentEndpoints are the POST endpoints that can be repeated safely:
+// exporting reads a file and the state changes leave the same state when
+// applied twice
+var idempotentEndpoints = map[string]bool{
+	"/api/v2/torrents/export": true,
+	"/api/v2/torrents/pause":  true,
+	"/api/v2/torrents/resume": true,
+	"/api/v2/torrents/stop":   true,
+	"/api/v2/torrents/start":  true,
+}
+
+// isDialError reports whether a request failed before it was sent because
+// no connection could be made
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// do sends an API request, logging in first if needed. Connection failures
+// and server errors are retried with backoff, a 403 triggers a fresh login,
+// and repeated failures open the circuit breaker. Other POST requests, such
+// as adds, deletes and preference changes, are only retried if qBittorrent
+// can't have acted on them, so a lost response never applies them twice.
+func (q *QBittorrentClient) do(method, endpoint string, body []byte, contentType string) ([]byte, error) {
+	if !q.breaker.allow() {
+		return nil, fmt.Errorf("%w: too many recent failures", ErrBackendUnavailable)
+	}
+	repeatable := method == http.MethodGet || idempotentEndpoints[endpoint]
+
+	var lastErr error
+retry:
+	for attempt := 0; attempt < q.retry.maxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(q.retry.backoff(attempt))
 		}
 
-		// If we got here, the session is likely expired
-		q.isLoggedIn = false
+		if err := q.ensureLoggedIn(); err != nil {
+			// Retrying won't fix wrong credentials
+			if errors.Is(err, ErrUnauthorized) {
+				q.breaker.success()
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
 
-		// Clean up if we got a response
-		if err == nil {
-			resp.Body.Close()
+		status, respBody, err := q.send(method, endpoint, body, contentType)
+		if err != nil {
+			lastErr = err
+			if !repeatable && !isDialError(err) {
+				break retry
+			}
+			continue
+		}
+
+		switch {
+		case status == http.StatusOK:
+			q.breaker.success()
+			return respBody, nil
+		case status == http.StatusForbidden:
+			// The session expired, log in again on the next attempt
+			q.markLoggedOut()
+			lastErr = fmt.Errorf("%w: session was rejected", ErrUnauthorized)
+		case status == http.StatusNotFound:
+			q.breaker.success()
+			return nil, fmt.Errorf("%s: %w", endpoint, ErrNotFound)
+		case status >= http.StatusInternalServerError:
+			lastErr = fmt.Errorf("request failed with status %d: %s", status, respBody)
+			if !repeatable {
+				break retry
+			}
+		default:
+			q.breaker.success()
+			return nil, fmt.Errorf("request failed with status %d: %s", status, respBody)
 		}
 	}
 
-	// Login required
-	return q.Login()
+	// A session that keeps being rejected is a credentials problem, not an outage
+	if errors.Is(lastErr, ErrUnauthorized) {
+		q.breaker.success()
+		return nil, lastErr
+	}
+
+	q.breaker.failure()
+	return nil, fmt.Errorf("%w: %w", ErrBackendUnavailable, lastErr)
 }
 
-// AddTorrent uploads a torrent file to qBittorrent and returns the added torrent's details
-func (q *QBittorrentClient) AddTorrent(torrentBytes []byte, savePath string) (*models.TorrentInfo, error) {
-	if err := q.ensureLoggedIn(); err != nil {
-		return nil, err
+// get sends a GET request with optional query parameters
+func (q *QBittorrentClient) get(endpoint string, params url.Values) ([]byte, error) {
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
 	}
+	return q.do(http.MethodGet, endpoint, nil, "")
+}
 
+// postForm sends a POST request with form data
+func (q *QBittorrentClient) postForm(endpoint string, data url.Values) ([]byte, error) {
+	return q.do(http.MethodPost, endpoint, []byte(data.Encode()), "application/x-www-form-urlencoded")
+}
+
+// getJSON sends a GET request and decodes the JSON response into v
+func (q *QBittorrentClient) getJSON(endpoint string, params url.Values, v any) error {
+	body, err := q.get(endpoint, params)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// AddTorrent uploads a torrent file to qBittorrent and returns the added torrent's details
+func (q *QBittorrentClient) AddTorrent(torrentBytes []byte, savePath string) (*models.TorrentInfo, error) {
 	// Validate torrent file
 	if len(torrentBytes) == 0 {
 		return nil, fmt.Errorf("torrent file is empty")
 	}
-
-	url := fmt.Sprintf("%s/api/v2/torrents/add", q.config.URL)
+	meta, err := utils.ParseTorrentMeta(torrentBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid torrent file: %w", err)
+	}
 
 	var buffer bytes.Buffer
 	writer := multipart.NewWriter(&buffer)
@@ -133,80 +422,93 @@ func (q *QBittorrentClient) AddTorrent(torrentBytes []byte, savePath string) (*m
 		return nil, fmt.Errorf("failed to close writer: %w", err)
 	}
 
-	// Create request
-	req, err := http.NewRequest("POST", url, &buffer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	return q.submitAdd(meta.InfoHash, buffer.Bytes(), writer.FormDataContentType())
+}
+
+// AddTorrentURL adds a torrent from a magnet link and returns
+// the added torrent's details
+func (q *QBittorrentClient) AddTorrentURL(link, savePath string) (*models.TorrentInfo, error) {
+	// The info hash identifies the added torrent among concurrent adds
+	hash := utils.MagnetInfoHash(link)
+	if hash == "" {
+		return nil, fmt.Errorf("not a magnet link with an info hash")
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("User-Agent", "TelegramTorrentBot")
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
 
-	// Send request
-	resp, err := q.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+	if err := writer.WriteField("urls", link); err != nil {
+		return nil, fmt.Errorf("failed to add link: %w", err)
+	}
+	if savePath != "" {
+		if err := writer.WriteField("savepath", savePath); err != nil {
+			return nil, fmt.Errorf("failed to add save path: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close writer: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Read the response body for more detailed error information
-	body, err := io.ReadAll(resp.Body)
+	return q.submitAdd(hash, buffer.Bytes(), writer.FormDataContentType())
+}
+
+// submitAdd sends a torrents/add request and returns the added torrent. An
+// add that failed after reaching qBittorrent is not sent again, as it would
+// be refused as a duplicate, so the torrent is looked up by hash instead.
+func (q *QBittorrentClient) submitAdd(hash string, body []byte, contentType string) (*models.TorrentInfo, error) {
+	resp, err := q.do(http.MethodPost, "/api/v2/torrents/add", body, contentType)
+	if errors.Is(err, ErrBackendUnavailable) {
+		if torrent, lookupErr := q.addedTorrent(hash); lookupErr == nil {
+			return torrent, nil
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to add torrent: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	// qBittorrent answers 200 with "Fails." when it can't add the torrent
+	if strings.Contains(string(resp), "Fails") {
+		return nil, fmt.Errorf("qBittorrent refused the torrent")
 	}
 
-	// Get the list of torrents to find the newly added one
-	torrents, err := q.GetTorrents("")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get torrents after adding: %w", err)
-	}
+	return q.addedTorrent(hash)
+}
 
-	// Find the most recently added torrent (assuming it's the one we just added)
-	var newestTorrent *models.TorrentInfo
-	var newestTime int64 = 0
-	for _, t := range torrents {
-		if t.AddedOn > newestTime {
-			newestTime = t.AddedOn
-			newestTorrent = &t
+// addedTorrent finds a torrent that was just added by its info hash.
+// qBittorrent may list a new torrent a moment after accepting it, so the
+// lookup is repeated a few times.
+func (q *QBittorrentClient) addedTorrent(hash string) (*models.TorrentInfo, error) {
+	const attempts = 5
+	const delay = 200 * time.Millisecond
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
 		}
-	}
 
-	if newestTorrent == nil {
-		return nil, fmt.Errorf("could not find newly added torrent")
+		var torrent *models.TorrentInfo
+		torrent, err = q.GetTorrentByHash(hash)
+		if err == nil {
+			return torrent, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			break
+		}
 	}
-
-	return newestTorrent, nil
+	return nil, fmt.Errorf("could not find newly added torrent: %w", err)
 }
 
 // GetTorrents returns information about torrents in qBittorrent
 func (q *QBittorrentClient) GetTorrents(filter string) ([]models.TorrentInfo, error) {
-	if err := q.ensureLoggedIn(); err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("%s/api/v2/torrents/info", q.config.URL)
+	params := url.Values{}
 	if filter != "" {
-		url += "?filter=" + filter
-	}
-
-	resp, err := q.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get torrents: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		params.Set("filter", q.torrentFilter(filter))
 	}
 
 	var torrents []models.TorrentInfo
-	if err := json.Unmarshal(body, &torrents); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := q.getJSON("/api/v2/torrents/info", params, &torrents); err != nil {
+		return nil, fmt.Errorf("failed to get torrents: %w", err)
 	}
 
 	return torrents, nil
@@ -222,27 +524,32 @@ func (q *QBittorrentClient) ResumeTorrents(hashes []string) error {
 	return q.torrentAction("resume", hashes)
 }
 
-// DeleteTorrents deletes torrents with the given hashes
-func (q *QBittorrentClient) DeleteTorrents(hashes []string, deleteFiles bool) error {
-	if err := q.ensureLoggedIn(); err != nil {
-		return err
+// actionEndpoint returns the endpoint name for an action on the connected
+// qBittorrent version, mapping pause/resume to stop/start on 5.x
+func (q *QBittorrentClient) actionEndpoint(action string) string {
+	if !q.usesStartStop() {
+		return action
+	}
+
+	switch action {
+	case "pause":
+		return "stop"
+	case "resume":
+		return "start"
+	default:
+		return action
 	}
+}
 
-	link := fmt.Sprintf("%s/api/v2/torrents/delete", q.config.URL)
+// DeleteTorrents deletes torrents with the given hashes
+func (q *QBittorrentClient) DeleteTorrents(hashes []string, deleteFiles bool) error {
 	data := url.Values{
 		"hashes":      {strings.Join(hashes, "|")},
 		"deleteFiles": {fmt.Sprintf("%t", deleteFiles)},
 	}
 
-	resp, err := q.client.PostForm(link, data)
-	if err != nil {
-		return fmt.Errorf("delete request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("delete failed with status %d: %s", resp.StatusCode, body)
+	if _, err := q.postForm("/api/v2/torrents/delete", data); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
 	}
 
 	return nil
@@ -250,24 +557,112 @@ func (q *QBittorrentClient) DeleteTorrents(hashes []string, deleteFiles bool) er
 
 // torrentAction performs actions on torrents like pause, resume
 func (q *QBittorrentClient) torrentAction(action string, hashes []string) error {
+	// Log in first so the API version is known when choosing the endpoint
 	if err := q.ensureLoggedIn(); err != nil {
 		return err
 	}
 
-	link := fmt.Sprintf("%s/api/v2/torrents/%s", q.config.URL, action)
 	data := url.Values{
 		"hashes": {strings.Join(hashes, "|")},
 	}
 
-	resp, err := q.client.PostForm(link, data)
+	if _, err := q.postForm("/api/v2/torrents/"+q.actionEndpoint(action), data); err != nil {
+		return fmt.Errorf("%s failed: %w", action, err)
+	}
+
+	return nil
+}
+
+// ExportTorrent returns the .torrent file of a torrent in qBittorrent
+func (q *QBittorrentClient) ExportTorrent(hash string) ([]byte, error) {
+	// Log in first so the API version is known
+	if err := q.ensureLoggedIn(); err != nil {
+		return nil, err
+	}
+
+	if version := q.APIVersion(); !version.AtLeast(apiVersionExport) {
+		return nil, fmt.Errorf("exporting torrents requires qBittorrent 4.5 or newer (API %s)", version)
+	}
+
+	data := url.Values{
+		"hash": {hash},
+	}
+
+	body, err := q.postForm("/api/v2/torrents/export", data)
 	if err != nil {
-		return fmt.Errorf("%s request failed: %w", action, err)
+		return nil, fmt.Errorf("export failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("%s failed with status %d: %s", action, resp.StatusCode, body)
+	return body, nil
+}
+
+// GetLog returns entries from qBittorrent's main log. types is a bitmask of
+// models.LogType values; only entries with an ID greater than lastKnownID are
+// returned, so pass -1 to get the whole log.
+func (q *QBittorrentClient) GetLog(types models.LogType, lastKnownID int64) ([]models.LogEntry, error) {
+	params := url.Values{
+		"normal":        {strconv.FormatBool(types&models.LogNormal != 0)},
+		"info":          {strconv.FormatBool(types&models.LogInfo != 0)},
+		"warning":       {strconv.FormatBool(types&models.LogWarning != 0)},
+		"critical":      {strconv.FormatBool(types&models.LogCritical != 0)},
+		"last_known_id": {strconv.FormatInt(lastKnownID, 10)},
+	}
+
+	var entries []models.LogEntry
+	if err := q.getJSON("/api/v2/log/main", params, &entries); err != nil {
+		return nil, fmt.Errorf("failed to get log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetMainData returns the sync state of qBittorrent. Pass rid 0 to get a full update.
+func (q *QBittorrentClient) GetMainData(rid int64) (*models.MainData, error) {
+	params := url.Values{
+		"rid": {strconv.FormatInt(rid, 10)},
+	}
+
+	var data models.MainData
+	if err := q.getJSON("/api/v2/sync/maindata", params, &data); err != nil {
+		return nil, fmt.Errorf("failed to get sync data: %w", err)
+	}
+
+	return &data, nil
+}
+
+// GetFreeSpace returns the free space on qBittorrent's default save path disk
+func (q *QBittorrentClient) GetFreeSpace() (int64, error) {
+	data, err := q.GetMainData(0)
+	if err != nil {
+		return 0, err
+	}
+	return data.ServerState.FreeSpaceOnDisk, nil
+}
+
+// GetPreferences returns qBittorrent's application preferences
+func (q *QBittorrentClient) GetPreferences() (*models.Preferences, error) {
+	var prefs models.Preferences
+	if err := q.getJSON("/api/v2/app/preferences", nil, &prefs); err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	return &prefs, nil
+}
+
+// SetPreferences changes qBittorrent's application preferences. Only the keys
+// present in prefs are changed.
+func (q *QBittorrentClient) SetPreferences(prefs map[string]any) error {
+	encoded, err := json.Marshal(prefs)
+	if err != nil {
+		return fmt.Errorf("failed to encode preferences: %w", err)
+	}
+
+	data := url.Values{
+		"json": {string(encoded)},
+	}
+
+	if _, err := q.postForm("/api/v2/app/setPreferences", data); err != nil {
+		return fmt.Errorf("set preferences failed: %w", err)
 	}
 
 	return nil
@@ -306,26 +701,37 @@ func (q *QBittorrentClient) GetTorrentByHash(hash string) (*models.TorrentInfo,
 		}
 	}
 
-	return nil, fmt.Errorf("torrent with hash %s not found", hash)
+	return nil, fmt.Errorf("torrent with hash %s: %w", hash, ErrNotFound)
 }
 
 // Reconnect forces a new connection to qBittorrent
 func (q *QBittorrentClient) Reconnect() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	// Reset the client's jar to clear cookies
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		return fmt.Errorf("failed to create cookie jar: %w", err)
 	}
 
-	// Create a new client with the same timeout but fresh cookies
-	q.client = http.Client{
-		Jar:     jar,
-		Timeout: q.client.Timeout,
+	// Create a new client with the same transport and timeout but fresh cookies
+	q.client = &http.Client{
+		Jar:       jar,
+		Timeout:   q.client.Timeout,
+		Transport: q.client.Transport,
 	}
 
-	// Reset login status
+	// Reset login status and give the backend another chance
 	q.isLoggedIn = false
+	q.breaker.reset()
 
 	// Attempt to login
-	return q.Login()
+	if err := q.login(); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrBackendUnavailable, err)
+	}
+	return nil
 }
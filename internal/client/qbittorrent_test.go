@@ -0,0 +1,459 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"telegramBot/internal/client/qbtfake"
+	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
+)
+
+// newTestClient starts a fake qBittorrent and returns a client connected to it
+// with retry delays short enough for tests
+func newTestClient(t *testing.T) (*QBittorrentClient, *qbtfake.Server) {
+	t.Helper()
+
+	server := qbtfake.New("admin", "secret")
+	t.Cleanup(server.Close)
+
+	q, err := NewQBittorrentClient(models.QBittorrentCredentials{
+		URL:      server.URL,
+		Username: "admin",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewQBittorrentClient: %v", err)
+	}
+	q.retry = retryPolicy{maxAttempts: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+
+	return q, server
+}
+
+// addTestTorrent adds a torrent through the client and returns it
+func addTestTorrent(t *testing.T, q *QBittorrentClient, name string) *models.TorrentInfo {
+	t.Helper()
+
+	torrent, err := q.AddTorrent(qbtfake.MakeTorrent(name, 1<<20), "/downloads/"+name)
+	if err != nil {
+		t.Fatalf("AddTorrent: %v", err)
+	}
+	return torrent
+}
+
+func TestLoginDetectsAPIVersion(t *testing.T) {
+	q, server := newTestClient(t)
+	server.SetAPIVersion("2.11.2")
+
+	if err := q.Login(); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	want := APIVersion{Major: 2, Minor: 11, Patch: 2}
+	if got := q.APIVersion(); got != want {
+		t.Errorf("APIVersion() = %v, want %v", got, want)
+	}
+}
+
+func TestLoginFailsWithoutAPIVersion(t *testing.T) {
+	q, server := newTestClient(t)
+	server.SetAPIVersion("unknown")
+
+	if err := q.Login(); err == nil {
+		t.Fatal("Login succeeded without an API version")
+	}
+
+	// The failed login is not kept, so the next request logs in again
+	server.SetAPIVersion("2.11.2")
+	if _, err := q.GetTorrents(""); err != nil {
+		t.Fatalf("GetTorrents: %v", err)
+	}
+	if logins := server.Logins(); logins != 2 {
+		t.Errorf("logged in %d times, want 2", logins)
+	}
+	if got := q.APIVersion(); got.Minor != 11 {
+		t.Errorf("APIVersion() = %v, want 2.11.2", got)
+	}
+}
+
+func TestLoginBadCredentials(t *testing.T) {
+	q, _ := newTestClient(t)
+	q.config.Password = "wrong"
+
+	_, err := q.GetTorrents("")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("GetTorrents error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAddAndGetTorrents(t *testing.T) {
+	q, _ := newTestClient(t)
+
+	added := addTestTorrent(t, q, "ubuntu.iso")
+	if added.Name != "ubuntu.iso" || added.SavePath != "/downloads/ubuntu.iso" {
+		t.Errorf("AddTorrent returned %+v", added)
+	}
+
+	torrents, err := q.GetTorrentsByName("UBUNTU")
+	if err != nil {
+		t.Fatalf("GetTorrentsByName: %v", err)
+	}
+	if len(torrents) != 1 || torrents[0].Hash != added.Hash {
+		t.Errorf("GetTorrentsByName = %+v, want the added torrent", torrents)
+	}
+}
+
+func TestAddTorrentRejected(t *testing.T) {
+	q, _ := newTestClient(t)
+
+	if _, err := q.AddTorrent([]byte("d4:junke"), ""); err == nil {
+		t.Fatal("AddTorrent accepted an invalid torrent")
+	}
+}
+
+func TestAddTorrentURL(t *testing.T) {
+	const hash = "e18c6673b6ae2dd1d4ecdc9fd83936e8d22594ab"
+	const v2 = "a3f1b2c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f80"
+	tests := []struct {
+		name   string
+		magnet string
+		hash   string
+	}{
+		{"hex", "magnet:?xt=urn:btih:" + strings.ToUpper(hash) + "&dn=example", hash},
+		{"base32", "magnet:?xt=urn:btih:4GGGM45WVYW5DVHM3SP5QOJW5DJCLFFL&dn=example", hash},
+		{"lower-case base32", "magnet:?xt=urn:btih:4gggm45wvyw5dvhm3sp5qojw5djclffl", hash},
+		{"v2 only", "magnet:?xt=urn:btmh:1220" + v2 + "&dn=example", v2[:40]},
+		{"hybrid", "magnet:?xt=urn:btmh:1220" + v2 + "&xt=urn:btih:" + hash, hash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, fake := newTestClient(t)
+
+			torrent, err := q.AddTorrentURL(tt.magnet, "/downloads/example")
+			if err != nil {
+				t.Fatalf("AddTorrentURL: %v", err)
+			}
+			if torrent.Hash != tt.hash || torrent.SavePath != "/downloads/example" {
+				t.Errorf("AddTorrentURL returned %+v, want hash %s", torrent, tt.hash)
+			}
+			if urls := fake.AddedURLs(); len(urls) != 1 || urls[0] != tt.magnet {
+				t.Errorf("AddedURLs = %v, want [%s]", urls, tt.magnet)
+			}
+		})
+	}
+
+	// Links without a usable hash are refused before reaching qBittorrent
+	q, fake := newTestClient(t)
+	for _, magnet := range []string{
+		"magnet:?dn=example",
+		"magnet:?xt=urn:btih:" + hash[:39],
+		"magnet:?xt=urn:btih:4GGGM45WVYW5DVHM3SP5QOJW5DJCLFF1",
+		"magnet:?xt=urn:btmh:1114" + v2[:40],
+	} {
+		if _, err := q.AddTorrentURL(magnet, "/downloads"); err == nil {
+			t.Errorf("AddTorrentURL(%s) succeeded", magnet)
+		}
+	}
+	if urls := fake.AddedURLs(); len(urls) != 0 {
+		t.Errorf("invalid magnets reached qBittorrent: %v", urls)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	tests := []struct {
+		apiVersion  string
+		pausedState string
+	}{
+		{"2.9.3", "pausedDL"},
+		{"2.11.2", "stoppedDL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.apiVersion, func(t *testing.T) {
+			q, server := newTestClient(t)
+			server.SetAPIVersion(tt.apiVersion)
+			torrent := addTestTorrent(t, q, "debian.iso")
+
+			if err := q.PauseTorrents([]string{torrent.Hash}); err != nil {
+				t.Fatalf("PauseTorrents: %v", err)
+			}
+			got, _ := server.Torrent(torrent.Hash)
+			if got.State != tt.pausedState {
+				t.Errorf("state after pause = %q, want %q", got.State, tt.pausedState)
+			}
+			if got.Status() != models.StatePausedDownload {
+				t.Errorf("Status() after pause = %v, want Paused", got.Status())
+			}
+
+			paused, err := q.GetTorrents("paused")
+			if err != nil {
+				t.Fatalf("GetTorrents(paused): %v", err)
+			}
+			if len(paused) != 1 {
+				t.Errorf("GetTorrents(paused) returned %d torrents, want 1", len(paused))
+			}
+
+			if err := q.ResumeTorrents([]string{torrent.Hash}); err != nil {
+				t.Fatalf("ResumeTorrents: %v", err)
+			}
+			got, _ = server.Torrent(torrent.Hash)
+			if got.State != "downloading" {
+				t.Errorf("state after resume = %q, want downloading", got.State)
+			}
+		})
+	}
+}
+
+func TestDeleteTorrents(t *testing.T) {
+	q, _ := newTestClient(t)
+	torrent := addTestTorrent(t, q, "fedora.iso")
+
+	if err := q.DeleteTorrents([]string{torrent.Hash}, true); err != nil {
+		t.Fatalf("DeleteTorrents: %v", err)
+	}
+
+	_, err := q.GetTorrentByHash(torrent.Hash)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTorrentByHash after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestExportTorrent(t *testing.T) {
+	q, server := newTestClient(t)
+	data := qbtfake.MakeTorrent("arch.iso", 4096)
+
+	torrent, err := q.AddTorrent(data, "")
+	if err != nil {
+		t.Fatalf("AddTorrent: %v", err)
+	}
+
+	exported, err := q.ExportTorrent(torrent.Hash)
+	if err != nil {
+		t.Fatalf("ExportTorrent: %v", err)
+	}
+	if !bytes.Equal(exported, data) {
+		t.Error("exported torrent differs from the added one")
+	}
+
+	// Export isn't available before API 2.8.14
+	server.SetAPIVersion("2.8.3")
+	if err := q.Reconnect(); err != nil {
+		t.Fatalf("Reconnect: %v", err)
+	}
+	if _, err := q.ExportTorrent(torrent.Hash); err == nil {
+		t.Error("ExportTorrent succeeded on an old API version")
+	}
+}
+
+func TestSessionExpiryRelogin(t *testing.T) {
+	q, server := newTestClient(t)
+	addTestTorrent(t, q, "mint.iso")
+
+	server.ExpireSessions()
+
+	torrents, err := q.GetTorrents("")
+	if err != nil {
+		t.Fatalf("GetTorrents after session expiry: %v", err)
+	}
+	if len(torrents) != 1 {
+		t.Errorf("GetTorrents returned %d torrents, want 1", len(torrents))
+	}
+	if logins := server.Logins(); logins != 2 {
+		t.Errorf("Logins() = %d, want 2", logins)
+	}
+}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	for _, status := range []int{0, http.StatusBadGateway} {
+		q, server := newTestClient(t)
+		if err := q.Login(); err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+
+		server.FailNext(status, 2)
+		if _, err := q.GetTorrents(""); err != nil {
+			t.Errorf("GetTorrents with %d injected failures (status %d): %v", 2, status, err)
+		}
+	}
+}
+
+func TestPostsAreNotRepeated(t *testing.T) {
+	q, server := newTestClient(t)
+	if err := q.Login(); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	data := qbtfake.MakeTorrent("lost-response.iso", 1<<20)
+
+	// The add went through but its response was lost, so the torrent is
+	// found by hash instead of being added again
+	server.DropResponsesNext(1)
+	torrent, err := q.AddTorrent(data, "/downloads")
+	if err != nil {
+		t.Fatalf("AddTorrent with a lost response: %v", err)
+	}
+	if torrent.Name != "lost-response.iso" {
+		t.Errorf("AddTorrent returned %+v", torrent)
+	}
+	if n := server.Requests("/api/v2/torrents/add"); n != 1 {
+		t.Errorf("add was sent %d times, want once", n)
+	}
+
+	// A failed add is reported rather than retried
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	server.FailNext(http.StatusBadGateway, 1)
+	if _, err := q.AddTorrentURL("magnet:?xt=urn:btih:"+hash, "/downloads"); !errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("AddTorrentURL error = %v, want ErrBackendUnavailable", err)
+	}
+	if n := server.Requests("/api/v2/torrents/add"); n != 2 {
+		t.Errorf("add was sent %d times in total, want twice", n)
+	}
+
+	// So is a delete
+	server.FailNext(http.StatusBadGateway, 1)
+	if err := q.DeleteTorrents([]string{torrent.Hash}, false); err == nil {
+		t.Error("DeleteTorrents succeeded after a server error")
+	}
+	if n := server.Requests("/api/v2/torrents/delete"); n != 1 {
+		t.Errorf("delete was sent %d times, want once", n)
+	}
+
+	// Pausing twice is harmless, so it is retried
+	server.FailNext(http.StatusBadGateway, 1)
+	if err := q.PauseTorrents([]string{torrent.Hash}); err != nil {
+		t.Errorf("PauseTorrents after a server error: %v", err)
+	}
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	q, server := newTestClient(t)
+	if err := q.Login(); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	q.breaker = newCircuitBreaker(2, time.Hour)
+
+	server.FailNext(http.StatusServiceUnavailable, 2*q.retry.maxAttempts)
+	for range 2 {
+		if _, err := q.GetTorrents(""); !errors.Is(err, ErrBackendUnavailable) {
+			t.Fatalf("GetTorrents error = %v, want ErrBackendUnavailable", err)
+		}
+	}
+
+	// The breaker is open, so requests must fail without reaching the server
+	before := server.Requests("/api/v2/torrents/info")
+	if _, err := q.GetTorrents(""); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("GetTorrents with open breaker error = %v, want ErrBackendUnavailable", err)
+	}
+	if after := server.Requests("/api/v2/torrents/info"); after != before {
+		t.Errorf("request reached the server while the breaker was open")
+	}
+
+	// Reconnect closes the breaker again
+	if err := q.Reconnect(); err != nil {
+		t.Fatalf("Reconnect: %v", err)
+	}
+	if _, err := q.GetTorrents(""); err != nil {
+		t.Errorf("GetTorrents after reconnect: %v", err)
+	}
+}
+
+func TestGetLog(t *testing.T) {
+	q, server := newTestClient(t)
+	server.AddLog(models.LogInfo, "started")
+	server.AddLog(models.LogWarning, "tracker timed out")
+	server.AddLog(models.LogCritical, "disk full")
+
+	entries, err := q.GetLog(models.LogWarning|models.LogCritical, -1)
+	if err != nil {
+		t.Fatalf("GetLog: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Message != "disk full" {
+		t.Errorf("GetLog = %+v, want the warning and critical entries", entries)
+	}
+
+	entries, err = q.GetLog(models.LogWarning|models.LogCritical, entries[0].ID)
+	if err != nil {
+		t.Fatalf("GetLog with cursor: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("GetLog with cursor returned %d entries, want 1", len(entries))
+	}
+}
+
+func TestPreferencesAndFreeSpace(t *testing.T) {
+	q, server := newTestClient(t)
+	server.SetFreeSpace(42 << 30)
+
+	free, err := q.GetFreeSpace()
+	if err != nil {
+		t.Fatalf("GetFreeSpace: %v", err)
+	}
+	if free != 42<<30 {
+		t.Errorf("GetFreeSpace() = %d, want %d", free, int64(42<<30))
+	}
+
+	if err := q.SetPreferences(map[string]any{"max_active_downloads": 7}); err != nil {
+		t.Fatalf("SetPreferences: %v", err)
+	}
+	prefs, err := q.GetPreferences()
+	if err != nil {
+		t.Fatalf("GetPreferences: %v", err)
+	}
+	if prefs.MaxActiveDownloads != 7 || !prefs.QueueingEnabled {
+		t.Errorf("GetPreferences() = %+v, want max_active_downloads 7 with queueing", prefs)
+	}
+}
+
+func TestParseAPIVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    APIVersion
+		wantErr bool
+	}{
+		{"2.11.2", APIVersion{2, 11, 2}, false},
+		{"2.8\n", APIVersion{2, 8, 0}, false},
+		{"v2.8", APIVersion{}, true},
+		{"2", APIVersion{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAPIVersion(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAPIVersion(%q) = %v, %v; want %v, error %t", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAddTorrentConcurrent(t *testing.T) {
+	q, _ := newTestClient(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := qbtfake.MakeTorrent(fmt.Sprintf("concurrent-%d", i), 1<<20)
+			meta, err := utils.ParseTorrentMeta(data)
+			if err != nil {
+				t.Errorf("ParseTorrentMeta: %v", err)
+				return
+			}
+
+			torrent, err := q.AddTorrent(data, "")
+			if err != nil {
+				t.Errorf("AddTorrent: %v", err)
+				return
+			}
+			if torrent.Hash != meta.InfoHash {
+				t.Errorf("AddTorrent(%s) returned torrent %s", meta.Name, torrent.Name)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
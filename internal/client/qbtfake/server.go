@@ -0,0 +1,561 @@
+// Package qbtfake provides an in-process fake of the qBittorrent WebUI API
+// for tests. It supports cookie based logins, adding and listing torrents,
+// pause/resume (stop/start on API 2.11+), delete, export, sync, log and
+// preferences endpoints, plus session expiry and error injection.
+package qbtfake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
+)
+
+// sessionCookie is the name of qBittorrent's session cookie
+const sessionCookie = "SID"
+
+// Server is a fake qBittorrent WebUI backed by httptest.Server
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	username   string
+	password   string
+	apiVersion string
+	sessions   map[string]bool
+	torrents   map[string]*models.TorrentInfo
+	files      map[string][]byte
+	urls       []string
+	deleted    map[string]bool
+	prefs      map[string]any
+	logs       []models.LogEntry
+	freeSpace  int64
+	clock      int64
+	logins     int
+	requests   map[string]int
+	failures   []int
+	drops      int
+}
+
+// New starts a fake qBittorrent that accepts the given credentials and
+// reports API version 2.9.3 (qBittorrent 4.6)
+func New(username, password string) *Server {
+	s := &Server{
+		username:   username,
+		password:   password,
+		apiVersion: "2.9.3",
+		sessions:   make(map[string]bool),
+		torrents:   make(map[string]*models.TorrentInfo),
+		files:      make(map[string][]byte),
+		deleted:    make(map[string]bool),
+		prefs: map[string]any{
+			"max_active_downloads": 3,
+			"max_active_uploads":   3,
+			"max_active_torrents":  5,
+			"queueing_enabled":     true,
+			"listen_port":          6881,
+			"save_path":            "/downloads",
+			"max_ratio_enabled":    false,
+			"max_ratio":            -1,
+		},
+		freeSpace: 100 << 30,
+		clock:     time.Now().Unix(),
+		requests:  make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", s.handleLogin)
+	mux.HandleFunc("/api/v2/app/version", s.authorized(s.handleVersion))
+	mux.HandleFunc("/api/v2/app/webapiVersion", s.authorized(s.handleWebAPIVersion))
+	mux.HandleFunc("/api/v2/app/preferences", s.authorized(s.handlePreferences))
+	mux.HandleFunc("/api/v2/app/setPreferences", s.authorized(s.handleSetPreferences))
+	mux.HandleFunc("/api/v2/torrents/add", s.authorized(s.handleAdd))
+	mux.HandleFunc("/api/v2/torrents/info", s.authorized(s.handleInfo))
+	mux.HandleFunc("/api/v2/torrents/delete", s.authorized(s.handleDelete))
+	mux.HandleFunc("/api/v2/torrents/export", s.authorized(s.handleExport))
+	mux.HandleFunc("/api/v2/torrents/", s.authorized(s.handleAction))
+	mux.HandleFunc("/api/v2/sync/maindata", s.authorized(s.handleMainData))
+	mux.HandleFunc("/api/v2/log/main", s.authorized(s.handleLog))
+
+	s.Server = httptest.NewServer(s.inject(mux))
+	return s
+}
+
+// SetAPIVersion changes the reported WebUI API version, e.g. "2.11.2" for qBittorrent 5
+func (s *Server) SetAPIVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiVersion = version
+}
+
+// ExpireSessions invalidates every session so clients get 403 until they log in again
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]bool)
+}
+
+// FailNext makes the next n requests fail with the given HTTP status.
+// A status of 0 drops the connection without a response.
+func (s *Server) FailNext(status, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for range n {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// DropResponsesNext makes the next n requests succeed but drops the
+// connection before the response is sent, like a timeout after qBittorrent
+// has already acted on the request
+func (s *Server) DropResponsesNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drops += n
+}
+
+// Logins returns the number of successful logins
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+// Requests returns how many requests reached the given path, including failed ones
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// SetFreeSpace sets the free disk space reported by sync/maindata
+func (s *Server) SetFreeSpace(bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.freeSpace = bytes
+}
+
+// AddLog appends an entry to the main log
+func (s *Server) AddLog(logType models.LogType, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, models.LogEntry{
+		ID:        int64(len(s.logs)),
+		Message:   message,
+		Timestamp: time.Now().Unix(),
+		Type:      logType,
+	})
+}
+
+// Torrent returns a copy of the torrent with the given hash
+func (s *Server) Torrent(hash string) (models.TorrentInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.torrents[strings.ToLower(hash)]
+	if !ok {
+		return models.TorrentInfo{}, false
+	}
+	return *t, true
+}
+
+// Complete marks a torrent as fully downloaded and seeding
+func (s *Server) Complete(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.torrents[strings.ToLower(hash)]; ok {
+		t.AmountLeft = 0
+		t.Progress = 1
+		t.State = "uploading"
+	}
+}
+
+// Deleted reports whether a torrent was deleted through torrents/delete and
+// whether its files were deleted with it
+func (s *Server) Deleted(hash string) (deleted, deleteFiles bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleteFiles, deleted = s.deleted[strings.ToLower(hash)]
+	return deleted, deleteFiles
+}
+
+// AddedURLs returns the magnet links and URLs added through torrents/add
+func (s *Server) AddedURLs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.urls...)
+}
+
+// Preference returns the current value of a preference
+func (s *Server) Preference(key string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prefs[key]
+}
+
+// inject counts requests and applies injected failures before routing
+func (s *Server) inject(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		status := -1
+		if len(s.failures) > 0 {
+			status = s.failures[0]
+			s.failures = s.failures[1:]
+		}
+		drop := status < 0 && s.drops > 0
+		if drop {
+			s.drops--
+		}
+		s.mu.Unlock()
+
+		if drop {
+			next.ServeHTTP(httptest.NewRecorder(), r)
+			dropConnection(w)
+			return
+		}
+
+		switch {
+		case status == 0:
+			dropConnection(w)
+		case status > 0:
+			http.Error(w, http.StatusText(status), status)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// dropConnection closes the connection without a response to simulate a
+// network failure
+func dropConnection(w http.ResponseWriter) {
+	if hj, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hj.Hijack(); err == nil {
+			conn.Close()
+			return
+		}
+	}
+	http.Error(w, "connection dropped", http.StatusBadGateway)
+}
+
+// authorized rejects requests without a valid session cookie with 403, like qBittorrent
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+
+		s.mu.Lock()
+		valid := err == nil && s.sessions[cookie.Value]
+		s.mu.Unlock()
+
+		if !valid {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// usesStartStop reports whether the fake behaves like qBittorrent 5.x; s.mu must be held
+func (s *Server) usesStartStop() bool {
+	parts := strings.Split(s.apiVersion, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	major, _ := strconv.Atoi(parts[0])
+	minor, _ := strconv.Atoi(parts[1])
+	return major > 2 || (major == 2 && minor >= 11)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.FormValue("username") != s.username || r.FormValue("password") != s.password {
+		io.WriteString(w, "Fails.")
+		return
+	}
+
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	sid := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	s.sessions[sid] = true
+	s.logins++
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: sid, Path: "/"})
+	io.WriteString(w, "Ok.")
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.usesStartStop() {
+		io.WriteString(w, "v5.0.0")
+	} else {
+		io.WriteString(w, "v4.6.0")
+	}
+}
+
+func (s *Server) handleWebAPIVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	io.WriteString(w, s.apiVersion)
+}
+
+func (s *Server) handlePreferences(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, s.prefs)
+}
+
+func (s *Server) handleSetPreferences(w http.ResponseWriter, r *http.Request) {
+	var changes map[string]any
+	if err := json.Unmarshal([]byte(r.FormValue("json")), &changes); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, value := range changes {
+		s.prefs[key] = value
+	}
+}
+
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	savePath := r.FormValue("savepath")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := false
+	for _, header := range r.MultipartForm.File["torrents"] {
+		f, err := header.Open()
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+
+		meta, err := utils.ParseTorrentMeta(data)
+		if err != nil {
+			continue
+		}
+
+		s.clock++
+		s.torrents[meta.InfoHash] = &models.TorrentInfo{
+			Name:       meta.Name,
+			Hash:       meta.InfoHash,
+			Size:       meta.TotalSize,
+			AmountLeft: meta.TotalSize,
+			State:      "downloading",
+			SavePath:   savePath,
+			AddedOn:    s.clock,
+			Eta:        8640000,
+		}
+		s.files[meta.InfoHash] = data
+		added = true
+	}
+
+	for _, link := range strings.Split(r.FormValue("urls"), "\n") {
+		if link = strings.TrimSpace(link); link == "" {
+			continue
+		}
+		s.urls = append(s.urls, link)
+		added = true
+
+		// Magnet links appear as torrents fetching metadata
+		if u, err := url.Parse(link); err == nil && u.Scheme == "magnet" {
+			hash := utils.MagnetInfoHash(link)
+			s.clock++
+			s.torrents[hash] = &models.TorrentInfo{
+				Name:     u.Query().Get("dn"),
+				Hash:     hash,
+				State:    "metaDL",
+				SavePath: savePath,
+				AddedOn:  s.clock,
+				Eta:      8640000,
+			}
+		}
+	}
+
+	if !added {
+		io.WriteString(w, "Fails.")
+		return
+	}
+	io.WriteString(w, "Ok.")
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	filter := r.URL.Query().Get("filter")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	torrents := make([]models.TorrentInfo, 0, len(s.torrents))
+	for _, t := range s.torrents {
+		if matchesFilter(t, filter) {
+			torrents = append(torrents, *t)
+		}
+	}
+	sort.Slice(torrents, func(i, j int) bool { return torrents[i].AddedOn < torrents[j].AddedOn })
+
+	writeJSON(w, torrents)
+}
+
+// matchesFilter reports whether a torrent matches a torrents/info filter
+func matchesFilter(t *models.TorrentInfo, filter string) bool {
+	state := t.Status()
+	switch filter {
+	case "", "all":
+		return true
+	case "downloading":
+		return state.IsDownloading()
+	case "seeding":
+		return state.IsSeeding()
+	case "paused", "stopped":
+		return state.IsPaused()
+	case "resumed", "running":
+		return !state.IsPaused()
+	default:
+		return false
+	}
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleteFiles := r.FormValue("deleteFiles") == "true"
+	for _, hash := range strings.Split(r.FormValue("hashes"), "|") {
+		hash = strings.ToLower(hash)
+		if _, ok := s.torrents[hash]; ok {
+			s.deleted[hash] = deleteFiles
+		}
+		delete(s.torrents, hash)
+		delete(s.files, hash)
+	}
+}
+
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[strings.ToLower(r.FormValue("hash"))]
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-bittorrent")
+	w.Write(data)
+}
+
+// handleAction implements pause/resume on 4.x and stop/start on 5.x
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request) {
+	action := strings.TrimPrefix(r.URL.Path, "/api/v2/torrents/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pausedState string
+	var running bool
+	switch {
+	case s.usesStartStop() && action == "stop", !s.usesStartStop() && action == "pause":
+		pausedState = "pausedDL"
+		if s.usesStartStop() {
+			pausedState = "stoppedDL"
+		}
+	case s.usesStartStop() && action == "start", !s.usesStartStop() && action == "resume":
+		running = true
+	default:
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	for _, hash := range strings.Split(r.FormValue("hashes"), "|") {
+		t, ok := s.torrents[strings.ToLower(hash)]
+		if !ok {
+			continue
+		}
+		if running {
+			t.State = "downloading"
+		} else {
+			t.State = pausedState
+		}
+	}
+}
+
+func (s *Server) handleMainData(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, models.MainData{
+		Rid:        1,
+		FullUpdate: true,
+		ServerState: models.ServerState{
+			FreeSpaceOnDisk:  s.freeSpace,
+			ConnectionStatus: "connected",
+		},
+	})
+}
+
+func (s *Server) handleLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	lastKnownID, err := strconv.ParseInt(query.Get("last_known_id"), 10, 64)
+	if err != nil {
+		lastKnownID = -1
+	}
+
+	wanted := map[models.LogType]bool{
+		models.LogNormal:   query.Get("normal") != "false",
+		models.LogInfo:     query.Get("info") != "false",
+		models.LogWarning:  query.Get("warning") != "false",
+		models.LogCritical: query.Get("critical") != "false",
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := []models.LogEntry{}
+	for _, entry := range s.logs {
+		if entry.ID > lastKnownID && wanted[entry.Type] {
+			entries = append(entries, entry)
+		}
+	}
+	writeJSON(w, entries)
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, fmt.Sprintf("encode: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// MakeTorrent builds a minimal single-file .torrent with the given name and size
+func MakeTorrent(name string, size int64) []byte {
+	info := fmt.Sprintf("d6:lengthi%de4:name%d:%s12:piece lengthi16384e6:pieces20:%se",
+		size, len(name), name, strings.Repeat("x", 20))
+	return []byte(fmt.Sprintf("d8:announce%d:%s4:info%se",
+		len("http://tracker.example/announce"), "http://tracker.example/announce", info))
+}
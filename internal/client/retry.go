@@ -0,0 +1,80 @@
+package client
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// retryPolicy controls how failed requests are retried
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// defaultRetryPolicy retries a request up to three times over a few seconds
+var defaultRetryPolicy = retryPolicy{
+	maxAttempts: 3,
+	baseDelay:   500 * time.Millisecond,
+	maxDelay:    5 * time.Second,
+}
+
+// backoff returns the delay before the given retry attempt (starting at 1),
+// using exponential backoff with full jitter
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return time.Duration(rand.Int64N(int64(delay)) + 1)
+}
+
+// circuitBreaker stops calls to a backend after repeated failures and lets
+// them through again after a cooldown
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+// newCircuitBreaker creates a breaker that opens after threshold consecutive failures
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow reports whether a call may be made. Once the cooldown has passed the
+// breaker is half-open: calls are allowed, and a single failure opens it again.
+func (c *circuitBreaker) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().After(c.openUntil)
+}
+
+// success records a successful call and closes the breaker
+func (c *circuitBreaker) success() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = 0
+	c.openUntil = time.Time{}
+}
+
+// failure records a failed call and opens the breaker when the threshold is reached
+func (c *circuitBreaker) failure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures++
+	if c.failures >= c.threshold {
+		c.openUntil = time.Now().Add(c.cooldown)
+	}
+}
+
+// reset closes the breaker, e.g. after a manual reconnect
+func (c *circuitBreaker) reset() {
+	c.success()
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// storedCookie is a cookie together with the URL it was set for
+type storedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// sessionJar is a cookie jar that remembers the cookies it receives so the
+// session can be saved and restored later
+type sessionJar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	cookies map[string]storedCookie
+}
+
+// newSessionJar creates an empty session jar
+func newSessionJar() (*sessionJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
+	}
+	return &sessionJar{jar: jar, cookies: make(map[string]storedCookie)}, nil
+}
+
+// SetCookies implements http.CookieJar
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	for _, c := range cookies {
+		key := u.Host + "|" + c.Domain + "|" + c.Path + "|" + c.Name
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(now)) {
+			delete(j.cookies, key)
+			continue
+		}
+
+		// Store an absolute expiry so restoring does not extend the cookie's life
+		stored := *c
+		if stored.MaxAge > 0 {
+			stored.Expires = now.Add(time.Duration(stored.MaxAge) * time.Second)
+			stored.MaxAge = 0
+		}
+		j.cookies[key] = storedCookie{URL: u.String(), Cookie: &stored}
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// snapshot returns the cookies that have not expired yet
+func (j *sessionJar) snapshot() []storedCookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	var cookies []storedCookie
+	for _, c := range j.cookies {
+		if c.Cookie.Expires.IsZero() || c.Cookie.Expires.After(now) {
+			cookies = append(cookies, c)
+		}
+	}
+	return cookies
+}
+
+// restore adds previously saved cookies to the jar and reports whether any were restored
+func (j *sessionJar) restore(cookies []storedCookie) bool {
+	restored := false
+	for _, c := range cookies {
+		u, err := url.Parse(c.URL)
+		if err != nil || c.Cookie == nil {
+			continue
+		}
+		if !c.Cookie.Expires.IsZero() && c.Cookie.Expires.Before(time.Now()) {
+			continue
+		}
+		j.SetCookies(u, []*http.Cookie{c.Cookie})
+		restored = true
+	}
+	return restored
+}
+
+// SessionStore saves tracker cookies to disk, encrypted with AES-GCM, one
+// file per tracker. A nil store does not persist anything.
+type SessionStore struct {
+	dir  string
+	aead cipher.AEAD
+}
+
+// NewSessionStore creates a session store in dir, encrypting sessions with a
+// key derived from secret
+func NewSessionStore(dir, secret string) (*SessionStore, error) {
+	if secret == "" {
+		return nil, errors.New("session encryption key is empty")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &SessionStore{dir: dir, aead: aead}, nil
+}
+
+// path returns the session file of a tracker
+func (s *SessionStore) path(trackerName string) string {
+	return filepath.Join(s.dir, trackerName+".session")
+}
+
+// load reads the saved cookies of a tracker. A missing file is not an error.
+func (s *SessionStore) load(trackerName string) ([]storedCookie, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(s.path(trackerName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("session file is corrupted")
+	}
+	plain, err := s.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(trackerName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt session: %w", err)
+	}
+
+	var cookies []storedCookie
+	if err := json.Unmarshal(plain, &cookies); err != nil {
+		return nil, fmt.Errorf("failed to parse session: %w", err)
+	}
+	return cookies, nil
+}
+
+// save writes the cookies of a tracker, replacing the previous session
+func (s *SessionStore) save(trackerName string, cookies []storedCookie) error {
+	if s == nil {
+		return nil
+	}
+
+	plain, err := json.Marshal(cookies)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	data := s.aead.Seal(nonce, nonce, plain, []byte(trackerName))
+
+	// Write to a temporary file first so a crash never leaves a truncated session
+	tmp := s.path(trackerName) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(trackerName)); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
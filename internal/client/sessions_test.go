@@ -0,0 +1,183 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a session store in a temporary directory
+func newTestStore(t *testing.T, secret string) *SessionStore {
+	t.Helper()
+	store, err := NewSessionStore(t.TempDir(), secret)
+	if err != nil {
+		t.Fatalf("NewSessionStore: %v", err)
+	}
+	return store
+}
+
+func TestSessionStoreRoundTrip(t *testing.T) {
+	store := newTestStore(t, "secret")
+	expires := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	cookies := []storedCookie{
+		{URL: "https://rutracker.org/forum/login.php", Cookie: &http.Cookie{Name: "bb_session", Value: "0-123-abc", Path: "/forum/", Domain: ".rutracker.org", Expires: expires, HttpOnly: true}},
+		{URL: "https://kinozal.tv/takelogin.php", Cookie: &http.Cookie{Name: "uid", Value: "42", Path: "/"}},
+	}
+
+	if err := store.save("rutracker", cookies); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	data, err := os.ReadFile(store.path("rutracker"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if len(data) == 0 || bytes.Contains(data, []byte("0-123-abc")) {
+		t.Error("session file is not encrypted")
+	}
+
+	got, err := store.load("rutracker")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(got) != len(cookies) {
+		t.Fatalf("loaded %d cookies, want %d", len(got), len(cookies))
+	}
+	for i, c := range got {
+		want := cookies[i]
+		if c.URL != want.URL || c.Cookie.Name != want.Cookie.Name || c.Cookie.Value != want.Cookie.Value ||
+			c.Cookie.Path != want.Cookie.Path || c.Cookie.Domain != want.Cookie.Domain ||
+			!c.Cookie.Expires.Equal(want.Cookie.Expires) || c.Cookie.HttpOnly != want.Cookie.HttpOnly {
+			t.Errorf("cookie %d = %+v, want %+v", i, c.Cookie, want.Cookie)
+		}
+	}
+}
+
+func TestSessionStoreRejectsOtherKeys(t *testing.T) {
+	store := newTestStore(t, "secret")
+	cookies := []storedCookie{{URL: "https://rutracker.org/", Cookie: &http.Cookie{Name: "bb_session", Value: "x"}}}
+	if err := store.save("rutracker", cookies); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// Another key can't decrypt the session
+	other, err := NewSessionStore(store.dir, "other secret")
+	if err != nil {
+		t.Fatalf("NewSessionStore: %v", err)
+	}
+	if _, err := other.load("rutracker"); err == nil {
+		t.Error("load with another key succeeded")
+	}
+
+	// A session copied to another tracker's file is refused
+	data, _ := os.ReadFile(store.path("rutracker"))
+	if err := os.WriteFile(store.path("kinozal"), data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := store.load("kinozal"); err == nil {
+		t.Error("load of a session saved for another tracker succeeded")
+	}
+
+	// So is a corrupted file
+	for _, corrupted := range [][]byte{data[:4], append(append([]byte{}, data[:len(data)-1]...), data[len(data)-1]^1)} {
+		if err := os.WriteFile(store.path("rutracker"), corrupted, 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if _, err := store.load("rutracker"); err == nil {
+			t.Error("load of a corrupted session succeeded")
+		}
+	}
+}
+
+func TestSessionStoreMissing(t *testing.T) {
+	store := newTestStore(t, "secret")
+	if cookies, err := store.load("rutracker"); err != nil || cookies != nil {
+		t.Errorf("load of a missing session = %v, %v, want nothing", cookies, err)
+	}
+
+	var none *SessionStore
+	if err := none.save("rutracker", nil); err != nil {
+		t.Errorf("save on a nil store: %v", err)
+	}
+	if cookies, err := none.load("rutracker"); err != nil || cookies != nil {
+		t.Errorf("load on a nil store = %v, %v, want nothing", cookies, err)
+	}
+
+	if _, err := NewSessionStore(t.TempDir(), ""); err == nil {
+		t.Error("NewSessionStore accepted an empty key")
+	}
+}
+
+func TestSessionJarExpiry(t *testing.T) {
+	jar, err := newSessionJar()
+	if err != nil {
+		t.Fatalf("newSessionJar: %v", err)
+	}
+	u, _ := url.Parse("https://kinozal.tv/takelogin.php")
+
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "uid", Value: "42", MaxAge: 3600},
+		{Name: "pass", Value: "hash"},
+		{Name: "old", Value: "gone", Expires: time.Now().Add(-time.Hour)},
+	})
+
+	saved := make(map[string]*http.Cookie)
+	for _, c := range jar.snapshot() {
+		saved[c.Cookie.Name] = c.Cookie
+	}
+	if len(saved) != 2 || saved["uid"] == nil || saved["pass"] == nil {
+		t.Fatalf("snapshot = %v, want uid and pass", saved)
+	}
+
+	// Max-Age is stored as an absolute expiry so restoring doesn't extend it
+	uid := saved["uid"]
+	if uid.MaxAge != 0 || time.Until(uid.Expires) < 59*time.Minute || time.Until(uid.Expires) > time.Hour {
+		t.Errorf("uid expires at %v with Max-Age %d, want an hour from now", uid.Expires, uid.MaxAge)
+	}
+	if !saved["pass"].Expires.IsZero() {
+		t.Errorf("session cookie got an expiry: %v", saved["pass"].Expires)
+	}
+
+	// Deleting a cookie drops it from the saved session too
+	jar.SetCookies(u, []*http.Cookie{{Name: "uid", MaxAge: -1}})
+	for _, c := range jar.snapshot() {
+		if c.Cookie.Name == "uid" {
+			t.Error("deleted cookie is still saved")
+		}
+	}
+}
+
+func TestSessionJarRestore(t *testing.T) {
+	u, _ := url.Parse("https://rutracker.org/forum/login.php")
+	saved := []storedCookie{
+		{URL: u.String(), Cookie: &http.Cookie{Name: "bb_session", Value: "abc", Path: "/forum/", Expires: time.Now().Add(time.Hour)}},
+		{URL: u.String(), Cookie: &http.Cookie{Name: "expired", Value: "x", Path: "/forum/", Expires: time.Now().Add(-time.Minute)}},
+		{URL: "::bad url", Cookie: &http.Cookie{Name: "bad", Value: "x"}},
+		{URL: u.String()},
+	}
+
+	jar, err := newSessionJar()
+	if err != nil {
+		t.Fatalf("newSessionJar: %v", err)
+	}
+	if !jar.restore(saved) {
+		t.Fatal("restore reported no cookies")
+	}
+
+	cookies := jar.Cookies(u)
+	if len(cookies) != 1 || cookies[0].Name != "bb_session" || cookies[0].Value != "abc" {
+		t.Errorf("cookies after restore = %v, want bb_session only", cookies)
+	}
+	if len(jar.snapshot()) != 1 {
+		t.Errorf("snapshot after restore has %d cookies, want 1", len(jar.snapshot()))
+	}
+
+	// A session with only expired cookies is not a session
+	empty, _ := newSessionJar()
+	if empty.restore(saved[1:]) {
+		t.Error("restore of expired cookies reported a session")
+	}
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegramBot/internal/models"
+)
+
+// maxTorznabResponseSize caps the search response read into memory
+const maxTorznabResponseSize = 16 << 20
+
+// torznabFeed is the RSS document returned by a Torznab search
+type torznabFeed struct {
+	XMLName xml.Name
+	Code    string        `xml:"code,attr"`
+	Message string        `xml:"description,attr"`
+	Items   []torznabItem `xml:"channel>item"`
+}
+
+// torznabItem is a single search result in a Torznab feed
+type torznabItem struct {
+	Title     string `xml:"title"`
+	GUID      string `xml:"guid"`
+	Link      string `xml:"link"`
+	Size      int64  `xml:"size"`
+	Jackett   string `xml:"jackettindexer"`
+	Prowlarr  string `xml:"prowlarrindexer"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+	Attrs []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"attr"`
+}
+
+// attr returns the value of a torznab:attr element
+func (i *torznabItem) attr(name string) string {
+	for _, a := range i.Attrs {
+		if a.Name == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// TorznabClient searches a Torznab endpoint such as Jackett or Prowlarr
+type TorznabClient struct {
+	client http.Client
+	config models.TorznabCredentials
+}
+
+// NewTorznabClient creates a client for the configured Torznab endpoint
+func NewTorznabClient(config models.TorznabCredentials) (*TorznabClient, error) {
+	if _, err := url.Parse(config.URL); err != nil || config.URL == "" {
+		return nil, fmt.Errorf("invalid Torznab URL: %q", config.URL)
+	}
+
+	return &TorznabClient{
+		client: http.Client{
+			Timeout: time.Minute,
+			// Indexers may redirect downloads to magnet links, which are returned as is
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if req.URL.Scheme == "magnet" {
+					return http.ErrUseLastResponse
+				}
+				if len(via) >= maxDirectRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxDirectRedirects)
+				}
+				return nil
+			},
+		},
+		config: config,
+	}, nil
+}
+
+// withoutURL returns the cause of a *url.Error without the request URL,
+// since Torznab search and download URLs carry the API key
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// Search runs a t=search query on the configured categories
+func (t *TorznabClient) Search(query string) ([]models.SearchResult, error) {
+	searchURL, err := url.Parse(t.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Torznab URL: %w", err)
+	}
+
+	params := searchURL.Query()
+	params.Set("t", "search")
+	params.Set("q", query)
+	if t.config.APIKey != "" {
+		params.Set("apikey", t.config.APIKey)
+	}
+	if len(t.config.Categories) > 0 {
+		categories := make([]string, len(t.config.Categories))
+		for i, category := range t.config.Categories {
+			categories[i] = strconv.Itoa(category)
+		}
+		params.Set("cat", strings.Join(categories, ","))
+	}
+	searchURL.RawQuery = params.Encode()
+
+	resp, err := t.client.Get(searchURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("search request failed: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTorznabResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(body) > maxTorznabResponseSize {
+		return nil, errors.New("search response is too large")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Jackett and Prowlarr explain failures such as a wrong API key in
+		// an <error> element, anything else is only worth its status code
+		if err := torznabError(body); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("search failed with status code: %d", resp.StatusCode)
+	}
+	return parseTorznabFeed(body)
+}
+
+// torznabError returns the error reported by an <error> document, or nil
+// if data is something else
+func torznabError(data []byte) error {
+	var feed torznabFeed
+	if err := xml.Unmarshal(data, &feed); err != nil || feed.XMLName.Local != "error" {
+		return nil
+	}
+	return fmt.Errorf("torznab error %s: %s", feed.Code, feed.Message)
+}
+
+// parseTorznabFeed extracts search results from a Torznab RSS document
+func parseTorznabFeed(data []byte) ([]models.SearchResult, error) {
+	var feed torznabFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse Torznab response: %w", err)
+	}
+
+	// Errors come back as <error code="..." description="..."/>
+	if feed.XMLName.Local == "error" {
+		return nil, fmt.Errorf("torznab error %s: %s", feed.Code, feed.Message)
+	}
+
+	results := make([]models.SearchResult, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		result := models.SearchResult{
+			Tracker: "torznab",
+			ID:      item.GUID,
+			Title:   strings.TrimSpace(item.Title),
+			Size:    item.Size,
+			Indexer: item.Jackett,
+		}
+		if result.Indexer == "" {
+			result.Indexer = item.Prowlarr
+		}
+		if result.Size == 0 {
+			result.Size = item.Enclosure.Length
+		}
+		if size, err := strconv.ParseInt(item.attr("size"), 10, 64); err == nil && result.Size == 0 {
+			result.Size = size
+		}
+
+		result.Seeds, _ = strconv.Atoi(item.attr("seeders"))
+		if peers, err := strconv.Atoi(item.attr("peers")); err == nil {
+			// Torznab peers include the seeders
+			result.Leechers = max(0, peers-result.Seeds)
+		}
+
+		// Prefer the magnet link, then the download link
+		result.Link = item.attr("magneturl")
+		if result.Link == "" {
+			result.Link = item.Enclosure.URL
+		}
+		if result.Link == "" {
+			result.Link = item.Link
+		}
+		if result.Link == "" {
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Download fetches a result's download link. Indexers either send the
+// .torrent file or redirect to a magnet link, so exactly one of the torrent
+// file and the magnet link is returned.
+func (t *TorznabClient) Download(link string) ([]byte, string, error) {
+	if strings.HasPrefix(link, "magnet:") {
+		return nil, link, nil
+	}
+
+	resp, err := t.client.Get(link)
+	if err != nil {
+		return nil, "", fmt.Errorf("download request failed: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if location := resp.Header.Get("Location"); strings.HasPrefix(location, "magnet:") {
+		return nil, location, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("download failed with status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDirectTorrentSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(body) > maxDirectTorrentSize {
+		return nil, "", errors.New("file is too large to be a torrent")
+	}
+	if len(body) < 10 || body[0] != 'd' {
+		return nil, "", errors.New("invalid torrent file format")
+	}
+
+	return body, "", nil
+}
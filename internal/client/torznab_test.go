@@ -0,0 +1,226 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"telegramBot/internal/models"
+)
+
+// jackettFeed is a Jackett search response, trimmed to two items
+const jackettFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="1.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+  <channel>
+    <atom:link href="http://127.0.0.1:9117/" rel="self" type="application/rss+xml" />
+    <title>AggregateSearch</title>
+    <description>This feed includes all configured trackers</description>
+    <link>http://127.0.0.1/</link>
+    <language>en-US</language>
+    <category>search</category>
+    <item>
+      <title>Ubuntu 24.04 LTS Desktop amd64</title>
+      <guid>https://rutracker.org/forum/viewtopic.php?t=6543210</guid>
+      <jackettindexer id="rutracker">RuTracker.org</jackettindexer>
+      <type>semi-private</type>
+      <comments>https://rutracker.org/forum/viewtopic.php?t=6543210</comments>
+      <pubDate>Thu, 25 Apr 2024 18:12:00 +0300</pubDate>
+      <size>6114770944</size>
+      <grabs>1520</grabs>
+      <description />
+      <link>http://127.0.0.1:9117/dl/rutracker/?jackett_apikey=secret&amp;path=abc&amp;file=Ubuntu</link>
+      <category>2020</category>
+      <enclosure url="http://127.0.0.1:9117/dl/rutracker/?jackett_apikey=secret&amp;path=abc&amp;file=Ubuntu" length="6114770944" type="application/x-bittorrent" />
+      <torznab:attr name="category" value="2020" />
+      <torznab:attr name="seeders" value="312" />
+      <torznab:attr name="peers" value="330" />
+      <torznab:attr name="downloadvolumefactor" value="1" />
+      <torznab:attr name="uploadvolumefactor" value="1" />
+    </item>
+    <item>
+      <title>Debian 12.5 netinst</title>
+      <guid>magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567</guid>
+      <jackettindexer id="rutor">RuTor</jackettindexer>
+      <size>659554304</size>
+      <link>http://127.0.0.1:9117/dl/rutor/?jackett_apikey=secret&amp;path=def&amp;file=Debian</link>
+      <torznab:attr name="seeders" value="45" />
+      <torznab:attr name="peers" value="47" />
+      <torznab:attr name="magneturl" value="magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&amp;dn=debian" />
+    </item>
+  </channel>
+</rss>`
+
+// prowlarrFeed is a Prowlarr search response, whose size only comes in
+// the enclosure and torznab attributes
+const prowlarrFeed = `<?xml version="1.0" encoding="utf-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+  <channel>
+    <atom:link rel="self" type="application/rss+xml" />
+    <title>Prowlarr</title>
+    <item>
+      <title>Fedora Workstation 40 x86_64</title>
+      <description />
+      <guid>https://kinozal.tv/details.php?id=1987654</guid>
+      <prowlarrindexer id="12" type="private">Kinozal</prowlarrindexer>
+      <comments>https://kinozal.tv/details.php?id=1987654</comments>
+      <pubDate>Tue, 23 Apr 2024 10:00:00 +0000</pubDate>
+      <link>http://localhost:9696/12/download?apikey=secret&amp;link=xyz&amp;file=Fedora</link>
+      <enclosure url="http://localhost:9696/12/download?apikey=secret&amp;link=xyz&amp;file=Fedora" length="0" type="application/x-bittorrent" />
+      <torznab:attr name="size" value="2295857152" />
+      <torznab:attr name="seeders" value="18" />
+      <torznab:attr name="peers" value="20" />
+    </item>
+    <item>
+      <title>Item without any link</title>
+      <guid>https://kinozal.tv/details.php?id=1</guid>
+      <prowlarrindexer id="12" type="private">Kinozal</prowlarrindexer>
+    </item>
+  </channel>
+</rss>`
+
+// torznabErrorFeed is what Jackett and Prowlarr send for a wrong API key
+const torznabErrorFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<error code="100" description="Invalid API Key" />`
+
+func TestParseTorznabFeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []models.SearchResult
+		wantErr string
+	}{
+		{
+			name: "jackett",
+			data: jackettFeed,
+			want: []models.SearchResult{
+				{
+					Tracker:  "torznab",
+					ID:       "https://rutracker.org/forum/viewtopic.php?t=6543210",
+					Title:    "Ubuntu 24.04 LTS Desktop amd64",
+					Size:     6114770944,
+					Seeds:    312,
+					Leechers: 18,
+					Indexer:  "RuTracker.org",
+					Link:     "http://127.0.0.1:9117/dl/rutracker/?jackett_apikey=secret&path=abc&file=Ubuntu",
+				},
+				{
+					Tracker:  "torznab",
+					ID:       "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567",
+					Title:    "Debian 12.5 netinst",
+					Size:     659554304,
+					Seeds:    45,
+					Leechers: 2,
+					Indexer:  "RuTor",
+					Link:     "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=debian",
+				},
+			},
+		},
+		{
+			name: "prowlarr",
+			data: prowlarrFeed,
+			want: []models.SearchResult{
+				{
+					Tracker:  "torznab",
+					ID:       "https://kinozal.tv/details.php?id=1987654",
+					Title:    "Fedora Workstation 40 x86_64",
+					Size:     2295857152,
+					Seeds:    18,
+					Leechers: 2,
+					Indexer:  "Kinozal",
+					Link:     "http://localhost:9696/12/download?apikey=secret&link=xyz&file=Fedora",
+				},
+			},
+		},
+		{
+			name: "empty channel",
+			data: `<rss version="2.0"><channel><title>Jackett</title></channel></rss>`,
+			want: []models.SearchResult{},
+		},
+		{
+			name:    "error",
+			data:    torznabErrorFeed,
+			wantErr: "torznab error 100: Invalid API Key",
+		},
+		{
+			name:    "not xml",
+			data:    "<html><body>Bad Gateway",
+			wantErr: "failed to parse Torznab response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTorznabFeed([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTorznabFeed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("results = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTorznabSearchStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"ok", http.StatusOK, prowlarrFeed, ""},
+		{"error document", http.StatusUnauthorized, torznabErrorFeed, "torznab error 100: Invalid API Key"},
+		{"html page", http.StatusBadGateway, "<html><body>Bad Gateway</body></html>", "status code: 502"},
+		{"feed with bad status", http.StatusInternalServerError, jackettFeed, "status code: 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			c, err := NewTorznabClient(models.TorznabCredentials{URL: server.URL + "/api", APIKey: "secret"})
+			if err != nil {
+				t.Fatalf("NewTorznabClient: %v", err)
+			}
+			_, err = c.Search("ubuntu")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Search: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTorznabErrorsHideAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	c, err := NewTorznabClient(models.TorznabCredentials{URL: server.URL + "/api", APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewTorznabClient: %v", err)
+	}
+
+	if _, err := c.Search("ubuntu"); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Search error = %v, want one without the API key", err)
+	}
+	if _, _, err := c.Download(server.URL + "/dl?apikey=secret"); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Download error = %v, want one without the API key", err)
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"telegramBot/internal/models"
+)
+
+// ErrLoggedOut is returned by a tracker when a response shows the session has expired
+var ErrLoggedOut = errors.New("tracker session has expired")
+
+// Tracker is a torrent tracker the bot can download from. Each implementation
+// lives in its own tracker_<name>.go file and registers itself in init.
+type Tracker interface {
+	// Name returns the identifier used in configuration and callbacks
+	Name() string
+	// DisplayName returns the name shown to users
+	DisplayName() string
+	// Domain returns the domain the tracker's cookies belong to, e.g. rutracker.org
+	Domain() string
+	// Hosts returns the default base URLs of the tracker's requests.
+	// Requests to them are sent to the configured mirrors.
+	Hosts() TrackerHosts
+	// MatchURL reports whether a link points to this tracker
+	MatchURL(u *url.URL) bool
+	// ExtractID returns the topic ID from a tracker link
+	ExtractID(u *url.URL) (string, error)
+	// Login authenticates the HTTP client with the tracker
+	Login(c *http.Client) error
+	// Download fetches the .torrent file for a topic ID. It returns
+	// ErrLoggedOut when the tracker asks to log in again.
+	Download(c *http.Client, id string) ([]byte, error)
+	// IsLoggedOut reports whether a response shows the session has expired
+	IsLoggedOut(resp *http.Response, body []byte) bool
+}
+
+// TrackerHosts are the default base URLs a tracker sends its requests to
+type TrackerHosts struct {
+	Site     string // Pages and the login form, e.g. https://kinozal.tv
+	Download string // .torrent downloads if they have their own host, e.g. https://dl.kinozal.tv
+}
+
+// Searcher is implemented by trackers that support searching for torrents
+type Searcher interface {
+	// Search returns the torrents matching query. It returns ErrLoggedOut
+	// when the tracker asks to log in again.
+	Search(c *http.Client, query string) ([]models.SearchResult, error)
+}
+
+// MagnetProvider is implemented by trackers that publish magnet links.
+// The bot prefers the magnet link and falls back to the .torrent file.
+type MagnetProvider interface {
+	// Magnet returns the magnet link for a topic ID
+	Magnet(c *http.Client, id string) (string, error)
+}
+
+// ProfileScraper is implemented by trackers with accounts that can report
+// the logged-in user's statistics
+type ProfileScraper interface {
+	// Profile scrapes the profile page of the logged-in user
+	Profile(c *http.Client) (*models.TrackerProfile, error)
+}
+
+// TopicScraper is implemented by trackers that can describe a topic
+type TopicScraper interface {
+	// Topic scrapes the topic page. It returns ErrTopicClosed or
+	// ErrTopicAbsorbed when the topic can't be downloaded.
+	Topic(c *http.Client, id string) (*models.TopicInfo, error)
+}
+
+// CaptchaSolver is implemented by trackers that may ask for a captcha when logging in
+type CaptchaSolver interface {
+	// LoginWithCaptcha logs in again with the answer to a captcha challenge
+	LoginWithCaptcha(c *http.Client, challenge *CaptchaChallenge, answer string) error
+}
+
+// CaptchaChallenge is a captcha a tracker asked for while logging in
+type CaptchaChallenge struct {
+	Tracker     string     // Name of the tracker
+	ImageURL    string     // URL of the captcha picture
+	Fields      url.Values // Hidden form fields to send back with the answer
+	AnswerField string     // Name of the form field for the answer
+}
+
+// CaptchaError is returned by Login when the tracker asks for a captcha
+type CaptchaError struct {
+	Challenge *CaptchaChallenge
+}
+
+// Error implements error
+func (e *CaptchaError) Error() string {
+	return fmt.Sprintf("%s asks for a captcha to log in", e.Challenge.Tracker)
+}
+
+// Unwrap makes errors.Is(err, ErrCaptchaRequired) match captcha errors
+func (e *CaptchaError) Unwrap() error {
+	return ErrCaptchaRequired
+}
+
+// TrackerFactory creates a tracker from its configured credentials
+type TrackerFactory func(creds models.TrackerCredentials) Tracker
+
+// trackerRegistry holds the factories of all known trackers by name
+var trackerRegistry = map[string]TrackerFactory{}
+
+// RegisterTracker makes a tracker implementation available under name
+func RegisterTracker(name string, factory TrackerFactory) {
+	if _, exists := trackerRegistry[name]; exists {
+		panic("tracker already registered: " + name)
+	}
+	trackerRegistry[name] = factory
+}
+
+// RegisteredTrackers returns the names of all registered trackers in sorted order
+func RegisteredTrackers() []string {
+	names := make([]string, 0, len(trackerRegistry))
+	for name := range trackerRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// requireCredentials returns a *LoginError if no username is configured for a tracker
+func requireCredentials(t Tracker, creds models.TrackerCredentials) error {
+	if creds.Username == "" {
+		return &LoginError{Tracker: t.DisplayName(), Kind: ErrBadCredentials, Message: "no credentials configured"}
+	}
+	return nil
+}
+
+// postLoginForm submits a tracker login form and returns the resulting page
+// as UTF-8 text. It returns a *LoginError with ErrSiteDown when the tracker
+// can't be reached.
+func postLoginForm(c *http.Client, t Tracker, loginURL string, form url.Values) (string, error) {
+	resp, err := c.PostForm(loginURL, form)
+	if err != nil {
+		return "", &LoginError{Tracker: t.DisplayName(), Kind: ErrSiteDown, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &LoginError{
+			Tracker: t.DisplayName(),
+			Kind:    ErrSiteDown,
+			Message: fmt.Sprintf("status code %d", resp.StatusCode),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", &LoginError{Tracker: t.DisplayName(), Kind: ErrSiteDown, Message: err.Error()}
+	}
+
+	return decodePage(resp, body), nil
+}
+
+// fetchPage fetches a tracker page and returns it as UTF-8 text, detecting expired sessions
+func fetchPage(c *http.Client, t Tracker, pageURL string) (string, error) {
+	resp, err := c.Get(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || t.IsLoggedOut(resp, body) {
+		return "", ErrLoggedOut
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	}
+
+	return decodePage(resp, body), nil
+}
+
+// downloadTorrentFile fetches a .torrent file for tracker t, detecting
+// expired sessions and validating the basic torrent file structure
+func downloadTorrentFile(c *http.Client, t Tracker, downloadURL string) ([]byte, error) {
+	resp, err := c.Get(downloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || t.IsLoggedOut(resp, body) {
+		return nil, ErrLoggedOut
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status code: %d", resp.StatusCode)
+	}
+
+	if len(body) == 0 {
+		return nil, fmt.Errorf("downloaded torrent is empty")
+	}
+
+	// Validate basic torrent file structure
+	if len(body) < 10 || body[0] != 'd' {
+		return nil, fmt.Errorf("invalid torrent file format")
+	}
+
+	return body, nil
+}
@@ -0,0 +1,285 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
+)
+
+func init() {
+	RegisterTracker("kinozal", newKinozal)
+}
+
+// kinozalDomainRegex matches kinozal domains such as kinozal.tv or dl.kinozal.tv
+var kinozalDomainRegex = regexp.MustCompile(`(^|\.)kinozal\.[a-z]{2,4}$`)
+
+var (
+	// kinozalRowRegex splits search results into rows
+	kinozalRowRegex = regexp.MustCompile(`<tr class=['"]?(?:bg|first bg)['"]?`)
+	// kinozalTitleRegex captures the torrent ID and title from the details link
+	kinozalTitleRegex = regexp.MustCompile(`(?s)<a href=["']?/details\.php\?id=(\d+)["']?[^>]*>(.*?)</a>`)
+	// kinozalSizeRegex captures the human-readable size cell
+	kinozalSizeRegex = regexp.MustCompile(`(?s)<td class=['"]?s['"]?>\s*([\d.,]+\s*[ТГМК]?Б)\s*</td>`)
+	// kinozalSeedsRegex captures the number of seeders
+	kinozalSeedsRegex = regexp.MustCompile(`(?s)<td class=['"]?sl_s['"]?>\s*(\d+)`)
+	// kinozalPeersRegex captures the number of leechers
+	kinozalPeersRegex = regexp.MustCompile(`(?s)<td class=['"]?sl_p['"]?>\s*(\d+)`)
+
+	// kinozalTopicTitleRegex captures the title of a details page
+	kinozalTopicTitleRegex = regexp.MustCompile(`(?s)<h1[^>]*>\s*<a[^>]*>(.*?)</a>`)
+	// kinozalCategoryRegex captures the category name from its icon
+	kinozalCategoryRegex = regexp.MustCompile(`/pic/cat/\d+\.gif["']?[^>]*?(?:title|alt)=["']([^"']+)["']`)
+	// kinozalTopicSizeRegex captures the human-readable size on a details page
+	kinozalTopicSizeRegex = regexp.MustCompile(`Вес<span[^>]*>([^<(]+)`)
+	// kinozalTopicSeedsRegex captures the number of seeders on a details page
+	kinozalTopicSeedsRegex = regexp.MustCompile(`Раздают<span[^>]*>\s*(\d+)`)
+	// kinozalTopicLeechersRegex captures the number of leechers on a details page
+	kinozalTopicLeechersRegex = regexp.MustCompile(`Скачивают<span[^>]*>\s*(\d+)`)
+	// kinozalRegisteredRegex captures the upload date on a details page
+	kinozalRegisteredRegex = regexp.MustCompile(`Залит<span[^>]*>([^<]+)</span>`)
+	// kinozalPosterRegex captures the poster image tag
+	kinozalPosterRegex = regexp.MustCompile(`<img[^>]*class=["']?p200["']?[^>]*>`)
+	// srcRegex captures the src attribute of an image tag
+	srcRegex = regexp.MustCompile(`src=["']([^"']+)["']`)
+)
+
+// kinozalLoginCheck recognizes the result of a kinozal login
+var kinozalLoginCheck = loginCheck{
+	SessionCookie:  "uid",
+	ProfileMarker:  "logout.php",
+	BadCredentials: []string{"неверно указан", "неверный пароль"},
+	Banned:         []string{"заблокирован", "забанен"},
+	MessageRegex:   regexp.MustCompile(`(?s)<div class="?red"?[^>]*>(.*?)</div>`),
+}
+
+// kinozal implements Tracker for kinozal.tv
+type kinozal struct {
+	creds models.TrackerCredentials
+}
+
+// newKinozal creates the kinozal implementation
+func newKinozal(creds models.TrackerCredentials) Tracker {
+	if creds.LoginURL == "" {
+		creds.LoginURL = "https://kinozal.tv/takelogin.php"
+	}
+	return &kinozal{creds: creds}
+}
+
+// Name implements Tracker
+func (k *kinozal) Name() string { return "kinozal" }
+
+// DisplayName implements Tracker
+func (k *kinozal) DisplayName() string { return "Kinozal" }
+
+// Domain implements Tracker
+func (k *kinozal) Domain() string { return "kinozal.tv" }
+
+// Hosts implements Tracker
+func (k *kinozal) Hosts() TrackerHosts {
+	return TrackerHosts{Site: "https://kinozal.tv", Download: "https://dl.kinozal.tv"}
+}
+
+// MatchURL implements Tracker
+func (k *kinozal) MatchURL(u *url.URL) bool {
+	return kinozalDomainRegex.MatchString(linkHost(u))
+}
+
+// ExtractID returns the torrent ID of a kinozal link. Torrent pages,
+// their comments and file lists, and downloads from dl.kinozal.tv all carry
+// it in id=, as in /details.php?id=123 or /download.php?id=123, on the main
+// and mobile sites alike.
+func (k *kinozal) ExtractID(u *url.URL) (string, error) {
+	query := u.Query()
+	switch linkPage(u) {
+	case "details.php", "download.php", "comment.php", "get_srv_details.php":
+		if id := query.Get("id"); isNumeric(id) {
+			return id, nil
+		}
+		return "", unsupportedLink(k, "a torrent page without a torrent ID")
+	case "browse.php", "top.php", "persons.php":
+		return "", unsupportedLink(k, "search results")
+	case "userdetails.php", "my.php", "mytorrents.php":
+		return "", unsupportedLink(k, "a user page")
+	case "", "index.php":
+		return "", unsupportedLink(k, "the home page")
+	}
+
+	if segments := linkPath(u); len(segments) > 0 && strings.EqualFold(segments[0], "forum") {
+		return "", unsupportedLink(k, "the forum")
+	}
+	return "", unsupportedLink(k, "a page that is not a torrent")
+}
+
+// Login implements Tracker
+func (k *kinozal) Login(c *http.Client) error {
+	if err := requireCredentials(k, k.creds); err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"username": {k.creds.Username},
+		"password": {k.creds.Password},
+	}
+	page, err := postLoginForm(c, k, k.creds.LoginURL, form)
+	if err != nil {
+		return err
+	}
+	return kinozalLoginCheck.verify(c, k, k.creds.LoginURL, page)
+}
+
+// Download implements Tracker
+func (k *kinozal) Download(c *http.Client, id string) ([]byte, error) {
+	return downloadTorrentFile(c, k, "https://dl.kinozal.tv/download.php?id="+id)
+}
+
+// IsLoggedOut reports whether the page links to the login form
+func (k *kinozal) IsLoggedOut(resp *http.Response, body []byte) bool {
+	return bytes.Contains(body, []byte("takelogin.php"))
+}
+
+// Search implements Searcher using kinozal's browse.php
+func (k *kinozal) Search(c *http.Client, query string) ([]models.SearchResult, error) {
+	// Kinozal expects the query in Windows-1251
+	searchURL := "https://kinozal.tv/browse.php?s=" + url.QueryEscape(string(utils.EncodeWindows1251(query)))
+
+	page, err := fetchPage(c, k, searchURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKinozalSearch(page), nil
+}
+
+// parseKinozalSearch extracts search results from a browse.php page
+func parseKinozalSearch(page string) []models.SearchResult {
+	var results []models.SearchResult
+
+	rows := kinozalRowRegex.FindAllStringIndex(page, -1)
+	for i, row := range rows {
+		// Each row runs until the start of the next one
+		end := len(page)
+		if i+1 < len(rows) {
+			end = rows[i+1][0]
+		}
+		chunk := page[row[0]:end]
+
+		title := kinozalTitleRegex.FindStringSubmatch(chunk)
+		if title == nil {
+			continue
+		}
+
+		result := models.SearchResult{
+			Tracker: "kinozal",
+			ID:      title[1],
+			Title:   cleanText(title[2]),
+		}
+		if m := kinozalSizeRegex.FindStringSubmatch(chunk); m != nil {
+			result.Size, _ = utils.ParseSize(m[1])
+		}
+		if m := kinozalSeedsRegex.FindStringSubmatch(chunk); m != nil {
+			result.Seeds, _ = strconv.Atoi(m[1])
+		}
+		if m := kinozalPeersRegex.FindStringSubmatch(chunk); m != nil {
+			result.Leechers, _ = strconv.Atoi(m[1])
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// Topic implements TopicScraper using the details.php page
+func (k *kinozal) Topic(c *http.Client, id string) (*models.TopicInfo, error) {
+	topicURL := "https://kinozal.tv/details.php?id=" + id
+	page, err := fetchPage(c, k, topicURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKinozalTopic(page, topicURL, id)
+}
+
+// parseKinozalTopic extracts the topic details from a details.php page
+func parseKinozalTopic(page, topicURL, id string) (*models.TopicInfo, error) {
+	lower := strings.ToLower(page)
+	switch {
+	case strings.Contains(lower, "раздача поглощена"):
+		return nil, ErrTopicAbsorbed
+	case strings.Contains(lower, "раздача закрыта"):
+		return nil, ErrTopicClosed
+	}
+
+	title := kinozalTopicTitleRegex.FindStringSubmatch(page)
+	if title == nil {
+		return nil, fmt.Errorf("topic %s not found on kinozal", id)
+	}
+
+	topic := &models.TopicInfo{
+		Tracker: "kinozal",
+		ID:      id,
+		Title:   cleanText(title[1]),
+	}
+
+	if m := kinozalCategoryRegex.FindStringSubmatch(page); m != nil {
+		topic.Section = cleanText(m[1])
+	}
+	if m := kinozalTopicSizeRegex.FindStringSubmatch(page); m != nil {
+		topic.Size, _ = utils.ParseSize(cleanText(m[1]))
+	}
+	if m := kinozalTopicSeedsRegex.FindStringSubmatch(page); m != nil {
+		topic.Seeds, _ = strconv.Atoi(m[1])
+	}
+	if m := kinozalTopicLeechersRegex.FindStringSubmatch(page); m != nil {
+		topic.Leechers, _ = strconv.Atoi(m[1])
+	}
+	if m := kinozalRegisteredRegex.FindStringSubmatch(page); m != nil {
+		topic.Registered = cleanText(m[1])
+	}
+	if img := kinozalPosterRegex.FindString(page); img != "" {
+		if m := srcRegex.FindStringSubmatch(img); m != nil {
+			topic.PosterURL = resolveURL(topicURL, html.UnescapeString(m[1]))
+		}
+	}
+
+	return topic, nil
+}
+
+// kinozalProfileFields locates the statistics on a kinozal userdetails.php page
+var kinozalProfileFields = profileFields{
+	Uploaded:   profileMarkers("Раздал", "Отдал"),
+	Downloaded: profileMarkers("Скачал"),
+	Ratio:      profileMarkers("Рейтинг", "Ратио"),
+	Bonus:      profileMarkers("Бонусы", "Бонус"),
+}
+
+// Profile implements ProfileScraper. The user ID comes from the uid session cookie.
+func (k *kinozal) Profile(c *http.Client) (*models.TrackerProfile, error) {
+	var uid string
+	if c.Jar != nil {
+		for _, cookie := range c.Jar.Cookies(&url.URL{Scheme: "https", Host: k.Domain(), Path: "/"}) {
+			if cookie.Name == kinozalLoginCheck.SessionCookie {
+				uid = cookie.Value
+			}
+		}
+	}
+	if !isNumeric(uid) {
+		return nil, ErrLoggedOut
+	}
+
+	profile, err := fetchProfile(c, k, "https://kinozal.tv/userdetails.php?id="+uid, kinozalProfileFields)
+	if err != nil {
+		return nil, err
+	}
+	profile.Username = k.creds.Username
+	profile.MinRatio = k.creds.MinRatio
+	return profile, nil
+}
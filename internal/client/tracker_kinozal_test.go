@@ -0,0 +1,92 @@
+package client
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"telegramBot/internal/models"
+)
+
+func TestParseKinozalSearch(t *testing.T) {
+	want := []models.SearchResult{
+		{
+			Tracker:  "kinozal",
+			ID:       "1987654",
+			Title:    "Ubuntu 24.04 LTS / Ubuntu 24.04 LTS (2024) DVD",
+			Size:     11 << 29,
+			Seeds:    312,
+			Leechers: 18,
+		},
+		{
+			Tracker:  "kinozal",
+			ID:       "1900001",
+			Title:    "Fedora Workstation 40 & Spins / 2024",
+			Size:     700 << 20,
+			Seeds:    0,
+			Leechers: 2,
+		},
+		{
+			Tracker:  "kinozal",
+			ID:       "1800002",
+			Title:    "Debian 12.5 netinst [amd64]",
+			Size:     5 << 38,
+			Seeds:    7,
+			Leechers: 9,
+		},
+	}
+
+	got := parseKinozalSearch(readFixture(t, "kinozal_search.html"))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKinozalSearch =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := parseKinozalSearch(`<div class="bx2_0"><table class=t_peer><tr class=mn><td>Название</td></tr></table>Нет активных раздач</div>`); len(got) != 0 {
+		t.Errorf("parseKinozalSearch of an empty result page = %+v, want none", got)
+	}
+}
+
+func TestParseKinozalTopic(t *testing.T) {
+	page := readFixture(t, "kinozal_topic.html")
+	topicURL := "https://kinozal.tv/details.php?id=1987654"
+
+	want := &models.TopicInfo{
+		Tracker:    "kinozal",
+		ID:         "1987654",
+		Title:      "Ubuntu 24.04 LTS / Ubuntu 24.04 LTS & Server / 2024 / DVD",
+		Section:    "Linux",
+		Size:       11 << 29,
+		Seeds:      312,
+		Leechers:   18,
+		Registered: "25 апреля 2024 в 18:12",
+		PosterURL:  "https://kinozal.tv/i/poster/4/1/1987654.jpg",
+	}
+	got, err := parseKinozalTopic(page, topicURL, "1987654")
+	if err != nil {
+		t.Fatalf("parseKinozalTopic: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKinozalTopic =\n%+v\nwant\n%+v", got, want)
+	}
+
+	notice := `<div class="bx1 justify">`
+	tests := []struct {
+		name string
+		page string
+		want error
+	}{
+		{"closed", strings.Replace(page, notice, notice+"<b>Раздача закрыта</b> модератором", 1), ErrTopicClosed},
+		{"absorbed", strings.Replace(page, notice, notice+"<b>Раздача поглощена</b> новой раздачей", 1), ErrTopicAbsorbed},
+	}
+	for _, tt := range tests {
+		if _, err := parseKinozalTopic(tt.page, topicURL, "1987654"); !errors.Is(err, tt.want) {
+			t.Errorf("%s topic: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	missing := `<div class="content"><div class="bx1">Раздача не найдена</div></div>`
+	if _, err := parseKinozalTopic(missing, topicURL, "1987654"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing topic: error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
+)
+
+func init() {
+	RegisterTracker("nnmclub", newNNMClub)
+}
+
+var (
+	// nnmclubDomainRegex matches NNM-Club domains such as nnmclub.to or nnm-club.me
+	nnmclubDomainRegex = regexp.MustCompile(`(^|\.)nnm-?club\.[a-z]{2,4}$`)
+	// nnmclubDownloadRegex captures the attachment ID of the topic's .torrent file
+	nnmclubDownloadRegex = regexp.MustCompile(`download\.php\?id=(\d+)`)
+)
+
+// nnmclubLoginCheck recognizes the result of an NNM-Club login
+var nnmclubLoginCheck = loginCheck{
+	ProfileMarker:  "login.php?logout=true",
+	BadCredentials: []string{"неверное имя пользователя", "неверный пароль", "вы ввели неверное"},
+	Banned:         []string{"заблокирован", "забанен"},
+	MessageRegex:   regexp.MustCompile(`(?s)<span class="gen">(.*?)</span>`),
+}
+
+// nnmclub implements Tracker for nnmclub.to
+type nnmclub struct {
+	creds models.TrackerCredentials
+}
+
+// newNNMClub creates the NNM-Club implementation
+func newNNMClub(creds models.TrackerCredentials) Tracker {
+	if creds.LoginURL == "" {
+		creds.LoginURL = "https://nnmclub.to/forum/login.php"
+	}
+	if creds.LoginKey == "" {
+		// The login button value, sent in the forum's Windows-1251 encoding
+		creds.LoginKey = string(utils.EncodeWindows1251("Вход"))
+	}
+	return &nnmclub{creds: creds}
+}
+
+// Name implements Tracker
+func (n *nnmclub) Name() string { return "nnmclub" }
+
+// DisplayName implements Tracker
+func (n *nnmclub) DisplayName() string { return "NNM-Club" }
+
+// Domain implements Tracker
+func (n *nnmclub) Domain() string { return "nnmclub.to" }
+
+// Hosts implements Tracker
+func (n *nnmclub) Hosts() TrackerHosts {
+	return TrackerHosts{Site: "https://nnmclub.to"}
+}
+
+// MatchURL implements Tracker
+func (n *nnmclub) MatchURL(u *url.URL) bool {
+	return nnmclubDomainRegex.MatchString(linkHost(u))
+}
+
+// ExtractID returns the topic ID of a viewtopic.php link. Download links
+// carry an attachment ID rather than the topic's, so they are refused.
+func (n *nnmclub) ExtractID(u *url.URL) (string, error) {
+	query := u.Query()
+	switch linkPage(u) {
+	case "viewtopic.php":
+		if id := query.Get("t"); isNumeric(id) {
+			return id, nil
+		}
+		if query.Has("p") {
+			return "", unsupportedLink(n, "a single post, open the topic and send its link")
+		}
+		return "", unsupportedLink(n, "a topic without a topic ID")
+	case "download.php":
+		return "", unsupportedLink(n, "a file download, send the link of its topic")
+	case "viewforum.php":
+		return "", unsupportedLink(n, "a forum section")
+	case "tracker.php", "search.php":
+		return "", unsupportedLink(n, "search results")
+	case "profile.php", "privmsg.php":
+		return "", unsupportedLink(n, "a user page")
+	case "", "forum", "index.php":
+		return "", unsupportedLink(n, "the forum index")
+	default:
+		return "", unsupportedLink(n, "a page that is not a topic")
+	}
+}
+
+// Login implements Tracker
+func (n *nnmclub) Login(c *http.Client) error {
+	if err := requireCredentials(n, n.creds); err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"username": {n.creds.Username},
+		"password": {n.creds.Password},
+		"login":    {n.creds.LoginKey},
+	}
+	page, err := postLoginForm(c, n, n.creds.LoginURL, form)
+	if err != nil {
+		return err
+	}
+	return nnmclubLoginCheck.verify(c, n, n.creds.LoginURL, page)
+}
+
+// Download implements Tracker. The .torrent file is an attachment with its
+// own ID, so the topic page is fetched first to find the download link.
+func (n *nnmclub) Download(c *http.Client, id string) ([]byte, error) {
+	page, err := fetchPage(c, n, "https://nnmclub.to/forum/viewtopic.php?t="+id)
+	if err != nil {
+		return nil, err
+	}
+
+	m := nnmclubDownloadRegex.FindStringSubmatch(page)
+	if m == nil {
+		return nil, fmt.Errorf("no torrent file found on NNM-Club topic %s", id)
+	}
+
+	return downloadTorrentFile(c, n, "https://nnmclub.to/forum/download.php?id="+m[1])
+}
+
+// IsLoggedOut reports whether the page contains the login form
+func (n *nnmclub) IsLoggedOut(resp *http.Response, body []byte) bool {
+	return bytes.Contains(body, []byte(`action="login.php"`))
+}
+
+// nnmclubProfileLinkRegex captures the first link to a user profile, which
+// the forum header shows for the logged-in user
+var nnmclubProfileLinkRegex = regexp.MustCompile(`(<a[^>]*href="[^"]*profile\.php\?mode=viewprofile[^"]*"[^>]*>)`)
+
+// nnmclubProfileFields locates the statistics on an NNM-Club profile page
+var nnmclubProfileFields = profileFields{
+	Uploaded:   profileMarkers("Всего отдано", "Отдал"),
+	Downloaded: profileMarkers("Всего скачано", "Скачал"),
+	Ratio:      profileMarkers("Ратио", "Рейтинг"),
+	Bonus:      profileMarkers("Бонус"),
+}
+
+// Profile implements ProfileScraper
+func (n *nnmclub) Profile(c *http.Client) (*models.TrackerProfile, error) {
+	profile, err := fetchForumProfile(c, n, "https://nnmclub.to/forum/index.php", nnmclubProfileLinkRegex, nnmclubProfileFields)
+	if err != nil {
+		return nil, err
+	}
+	profile.Username = n.creds.Username
+	profile.MinRatio = n.creds.MinRatio
+	return profile, nil
+}
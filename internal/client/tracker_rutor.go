@@ -0,0 +1,104 @@
+package client
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"telegramBot/internal/models"
+)
+
+func init() {
+	RegisterTracker("rutor", newRutor)
+}
+
+var (
+	// rutorDomainRegex matches rutor domains such as rutor.info or rutor.is
+	rutorDomainRegex = regexp.MustCompile(`(^|\.)rutor\.[a-z]{2,4}$`)
+	// rutorMagnetRegex captures the magnet link on a torrent page
+	rutorMagnetRegex = regexp.MustCompile(`href="(magnet:\?xt=urn:btih:[^"]+)"`)
+)
+
+// rutor implements Tracker for rutor.info. It is a public tracker, so no
+// credentials are needed.
+type rutor struct {
+	creds models.TrackerCredentials
+}
+
+// newRutor creates the rutor implementation
+func newRutor(creds models.TrackerCredentials) Tracker {
+	return &rutor{creds: creds}
+}
+
+// Name implements Tracker
+func (r *rutor) Name() string { return "rutor" }
+
+// DisplayName implements Tracker
+func (r *rutor) DisplayName() string { return "Rutor" }
+
+// Domain implements Tracker
+func (r *rutor) Domain() string { return "rutor.info" }
+
+// Hosts implements Tracker
+func (r *rutor) Hosts() TrackerHosts {
+	return TrackerHosts{Site: "https://rutor.info", Download: "https://d.rutor.info"}
+}
+
+// MatchURL implements Tracker
+func (r *rutor) MatchURL(u *url.URL) bool {
+	return rutorDomainRegex.MatchString(linkHost(u))
+}
+
+// ExtractID returns the torrent ID from /torrent/<id> and /download/<id> paths
+func (r *rutor) ExtractID(u *url.URL) (string, error) {
+	segments := linkPath(u)
+	if len(segments) == 0 {
+		return "", unsupportedLink(r, "the home page")
+	}
+
+	switch strings.ToLower(segments[0]) {
+	case "torrent", "download":
+		if len(segments) >= 2 && isNumeric(segments[1]) {
+			return segments[1], nil
+		}
+		return "", unsupportedLink(r, "a torrent page without a torrent ID")
+	case "search":
+		return "", unsupportedLink(r, "search results")
+	case "browse", "top", "new":
+		return "", unsupportedLink(r, "a list of torrents")
+	default:
+		return "", unsupportedLink(r, "a page that is not a torrent")
+	}
+}
+
+// Login does nothing, rutor does not require an account
+func (r *rutor) Login(c *http.Client) error {
+	return nil
+}
+
+// Download implements Tracker
+func (r *rutor) Download(c *http.Client, id string) ([]byte, error) {
+	return downloadTorrentFile(c, r, "https://d.rutor.info/download/"+id)
+}
+
+// Magnet implements MagnetProvider by reading the link from the torrent page
+func (r *rutor) Magnet(c *http.Client, id string) (string, error) {
+	page, err := fetchPage(c, r, "https://rutor.info/torrent/"+id)
+	if err != nil {
+		return "", err
+	}
+
+	m := rutorMagnetRegex.FindStringSubmatch(page)
+	if m == nil {
+		return "", fmt.Errorf("no magnet link found on rutor torrent %s", id)
+	}
+	return html.UnescapeString(m[1]), nil
+}
+
+// IsLoggedOut always reports false since rutor has no sessions
+func (r *rutor) IsLoggedOut(resp *http.Response, body []byte) bool {
+	return false
+}
@@ -0,0 +1,355 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
+)
+
+func init() {
+	RegisterTracker("rutracker", newRutracker)
+}
+
+// rutrackerDomainRegex matches rutracker domains such as rutracker.org or rutracker.net
+var rutrackerDomainRegex = regexp.MustCompile(`(^|\.)rutracker\.[a-z]{2,4}$`)
+
+var (
+	// rutrackerRowRegex splits search results into rows, capturing the topic ID
+	rutrackerRowRegex = regexp.MustCompile(`<tr id="trs-tr-(\d+)"`)
+	// rutrackerTitleRegex captures the topic title link
+	rutrackerTitleRegex = regexp.MustCompile(`(?s)<a[^>]*class="[^"]*tLink[^"]*"[^>]*>(.*?)</a>`)
+	// rutrackerSectionRegex captures the forum section link
+	rutrackerSectionRegex = regexp.MustCompile(`(?s)<a[^>]*class="gen f[^"]*"[^>]*>(.*?)</a>`)
+	// rutrackerSizeRegex captures the size in bytes from the sortable size cell
+	rutrackerSizeRegex = regexp.MustCompile(`tor-size"[^>]*data-ts_text="(\d+)"`)
+	// rutrackerSeedsRegex captures the number of seeders
+	rutrackerSeedsRegex = regexp.MustCompile(`(?s)class="seedmed"[^>]*>\s*(\d+)`)
+	// rutrackerLeechersRegex captures the number of leechers
+	rutrackerLeechersRegex = regexp.MustCompile(`(?s)leechmed[^"]*"[^>]*>\s*(?:<[^>]*>\s*)*(\d+)`)
+
+	// rutrackerTopicTitleRegex captures the title of a topic page
+	rutrackerTopicTitleRegex = regexp.MustCompile(`(?s)<a[^>]*id="topic-title"[^>]*>(.*?)</a>`)
+	// rutrackerForumLinkRegex captures the forum links in a topic's breadcrumbs
+	rutrackerForumLinkRegex = regexp.MustCompile(`(?s)<a[^>]*href="viewforum\.php\?f=\d+"[^>]*>(.*?)</a>`)
+	// rutrackerTopicStatusRegex captures the moderation status of a topic's
+	// torrent, such as approved, closed or consumed
+	rutrackerTopicStatusRegex = regexp.MustCompile(`(?s)<span id="tor-status-resp"[^>]*>\s*<span class="tor-icon tor-([a-z-]+)"`)
+	// rutrackerTopicSizeRegex captures the human-readable size of a topic's torrent
+	rutrackerTopicSizeRegex = regexp.MustCompile(`(?s)<span id="tor-size-humn"[^>]*>(.*?)</span>`)
+	// rutrackerTopicSeedsRegex captures the number of seeders on a topic page
+	rutrackerTopicSeedsRegex = regexp.MustCompile(`(?s)class="seed"[^>]*>[^<]*<b>(\d+)</b>`)
+	// rutrackerTopicLeechersRegex captures the number of leechers on a topic page
+	rutrackerTopicLeechersRegex = regexp.MustCompile(`(?s)class="leech"[^>]*>[^<]*<b>(\d+)</b>`)
+	// rutrackerRegisteredRegex captures the registration date of a topic's torrent
+	rutrackerRegisteredRegex = regexp.MustCompile(`(?s)Зарегистрирован.*?<li>([^<]+)</li>`)
+	// rutrackerPosterRegex captures the first image in the topic post
+	rutrackerPosterRegex = regexp.MustCompile(`class="postImg[^"]*"\s+title="([^"]+)"`)
+
+	// rutrackerCaptchaImageRegex captures the captcha picture on the login page
+	rutrackerCaptchaImageRegex = regexp.MustCompile(`<img[^>]+src="([^"]*/captcha/[^"]+)"`)
+	// rutrackerCaptchaSIDRegex captures the captcha session ID
+	rutrackerCaptchaSIDRegex = regexp.MustCompile(`name="cap_sid"\s+value="([^"]+)"`)
+	// rutrackerCaptchaCodeRegex captures the name of the captcha answer field
+	rutrackerCaptchaCodeRegex = regexp.MustCompile(`name="(cap_code_[0-9a-f]+)"`)
+)
+
+// rutrackerLoginCheck recognizes the result of a rutracker login
+var rutrackerLoginCheck = loginCheck{
+	SessionCookie:  "bb_session",
+	ProfileMarker:  "logged-in-username",
+	BadCredentials: []string{"неверный пароль", "неверное имя пользователя"},
+	Banned:         []string{"заблокирован", "забанен"},
+	MessageRegex:   regexp.MustCompile(`(?s)<h4 class="warnColor1[^"]*"[^>]*>(.*?)</h4>`),
+}
+
+// rutracker implements Tracker for rutracker.org
+type rutracker struct {
+	creds models.TrackerCredentials
+}
+
+// newRutracker creates the rutracker implementation
+func newRutracker(creds models.TrackerCredentials) Tracker {
+	if creds.LoginURL == "" {
+		creds.LoginURL = "https://rutracker.org/forum/login.php"
+	}
+	if creds.MinRatio == 0 {
+		// Downloads are limited for accounts below this ratio
+		creds.MinRatio = 0.3
+	}
+	return &rutracker{creds: creds}
+}
+
+// Name implements Tracker
+func (r *rutracker) Name() string { return "rutracker" }
+
+// DisplayName implements Tracker
+func (r *rutracker) DisplayName() string { return "RuTracker" }
+
+// Domain implements Tracker
+func (r *rutracker) Domain() string { return "rutracker.org" }
+
+// Hosts implements Tracker
+func (r *rutracker) Hosts() TrackerHosts {
+	return TrackerHosts{Site: "https://rutracker.org"}
+}
+
+// MatchURL implements Tracker
+func (r *rutracker) MatchURL(u *url.URL) bool {
+	return rutrackerDomainRegex.MatchString(linkHost(u))
+}
+
+// ExtractID returns the topic ID of a rutracker link. Topic pages and
+// downloads carry it in t=, as in /forum/viewtopic.php?t=123 or
+// /forum/dl.php?t=123, on the main and mobile sites alike. Short links
+// carry it in the path, as in /t/123.
+func (r *rutracker) ExtractID(u *url.URL) (string, error) {
+	segments := linkPath(u)
+	if n := len(segments); n >= 2 && strings.EqualFold(segments[n-2], "t") && isNumeric(segments[n-1]) {
+		return segments[n-1], nil
+	}
+
+	query := u.Query()
+	switch linkPage(u) {
+	case "viewtopic.php", "dl.php":
+		if id := query.Get("t"); isNumeric(id) {
+			return id, nil
+		}
+		if query.Has("p") {
+			return "", unsupportedLink(r, "a single post, open the topic and send its link")
+		}
+		return "", unsupportedLink(r, "a topic without a topic ID")
+	case "viewforum.php":
+		return "", unsupportedLink(r, "a forum section")
+	case "tracker.php", "search.php":
+		return "", unsupportedLink(r, "search results")
+	case "profile.php", "privmsg.php":
+		return "", unsupportedLink(r, "a user page")
+	case "", "forum", "index.php":
+		return "", unsupportedLink(r, "the forum index")
+	default:
+		return "", unsupportedLink(r, "a page that is not a topic")
+	}
+}
+
+// Login implements Tracker. It returns a *CaptchaError when rutracker asks for a captcha.
+func (r *rutracker) Login(c *http.Client) error {
+	if err := requireCredentials(r, r.creds); err != nil {
+		return err
+	}
+	return r.submitLogin(c, r.loginForm())
+}
+
+// LoginWithCaptcha implements CaptchaSolver
+func (r *rutracker) LoginWithCaptcha(c *http.Client, challenge *CaptchaChallenge, answer string) error {
+	if err := requireCredentials(r, r.creds); err != nil {
+		return err
+	}
+
+	form := r.loginForm()
+	for key, values := range challenge.Fields {
+		form[key] = values
+	}
+	form.Set(challenge.AnswerField, answer)
+
+	return r.submitLogin(c, form)
+}
+
+// loginForm returns the login form fields with the configured credentials
+func (r *rutracker) loginForm() url.Values {
+	return url.Values{
+		"login_username": {r.creds.Username},
+		"login_password": {r.creds.Password},
+		"login":          {r.creds.LoginKey},
+	}
+}
+
+// submitLogin posts the login form and checks whether it succeeded or a captcha is required
+func (r *rutracker) submitLogin(c *http.Client, form url.Values) error {
+	page, err := postLoginForm(c, r, r.creds.LoginURL, form)
+	if err != nil {
+		return err
+	}
+
+	if challenge := parseRutrackerCaptcha(page, r.creds.LoginURL); challenge != nil {
+		return &CaptchaError{Challenge: challenge}
+	}
+	return rutrackerLoginCheck.verify(c, r, r.creds.LoginURL, page)
+}
+
+// parseRutrackerCaptcha extracts the captcha challenge from a login page, or
+// returns nil if the page does not ask for one
+func parseRutrackerCaptcha(page, loginURL string) *CaptchaChallenge {
+	image := rutrackerCaptchaImageRegex.FindStringSubmatch(page)
+	answer := rutrackerCaptchaCodeRegex.FindStringSubmatch(page)
+	if image == nil || answer == nil {
+		return nil
+	}
+
+	challenge := &CaptchaChallenge{
+		Tracker:     "rutracker",
+		ImageURL:    html.UnescapeString(image[1]),
+		Fields:      url.Values{},
+		AnswerField: answer[1],
+	}
+	if sid := rutrackerCaptchaSIDRegex.FindStringSubmatch(page); sid != nil {
+		challenge.Fields.Set("cap_sid", sid[1])
+	}
+
+	// The image link may be relative to the login page
+	challenge.ImageURL = resolveURL(loginURL, challenge.ImageURL)
+
+	return challenge
+}
+
+// Download implements Tracker
+func (r *rutracker) Download(c *http.Client, id string) ([]byte, error) {
+	return downloadTorrentFile(c, r, "https://rutracker.org/forum/dl.php?t="+id)
+}
+
+// IsLoggedOut reports whether the page contains the login form
+func (r *rutracker) IsLoggedOut(resp *http.Response, body []byte) bool {
+	return bytes.Contains(body, []byte(`name="login_username"`))
+}
+
+// Search implements Searcher using rutracker's tracker.php search page
+func (r *rutracker) Search(c *http.Client, query string) ([]models.SearchResult, error) {
+	// Rutracker expects the query in Windows-1251
+	searchURL := "https://rutracker.org/forum/tracker.php?nm=" + url.QueryEscape(string(utils.EncodeWindows1251(query)))
+
+	page, err := fetchPage(c, r, searchURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRutrackerSearch(page), nil
+}
+
+// parseRutrackerSearch extracts search results from a tracker.php page
+func parseRutrackerSearch(page string) []models.SearchResult {
+	var results []models.SearchResult
+
+	rows := rutrackerRowRegex.FindAllStringSubmatchIndex(page, -1)
+	for i, row := range rows {
+		// Each row runs until the start of the next one
+		end := len(page)
+		if i+1 < len(rows) {
+			end = rows[i+1][0]
+		}
+		chunk := page[row[0]:end]
+
+		title := rutrackerTitleRegex.FindStringSubmatch(chunk)
+		if title == nil {
+			continue
+		}
+
+		result := models.SearchResult{
+			Tracker: "rutracker",
+			ID:      page[row[2]:row[3]],
+			Title:   cleanText(title[1]),
+		}
+		if m := rutrackerSectionRegex.FindStringSubmatch(chunk); m != nil {
+			result.Section = cleanText(m[1])
+		}
+		if m := rutrackerSizeRegex.FindStringSubmatch(chunk); m != nil {
+			result.Size, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+		if m := rutrackerSeedsRegex.FindStringSubmatch(chunk); m != nil {
+			result.Seeds, _ = strconv.Atoi(m[1])
+		}
+		if m := rutrackerLeechersRegex.FindStringSubmatch(chunk); m != nil {
+			result.Leechers, _ = strconv.Atoi(m[1])
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// Topic implements TopicScraper using the viewtopic.php page
+func (r *rutracker) Topic(c *http.Client, id string) (*models.TopicInfo, error) {
+	topicURL := "https://rutracker.org/forum/viewtopic.php?t=" + id
+	page, err := fetchPage(c, r, topicURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRutrackerTopic(page, topicURL, id)
+}
+
+// parseRutrackerTopic extracts the topic details from a viewtopic.php page
+func parseRutrackerTopic(page, topicURL, id string) (*models.TopicInfo, error) {
+	// Only the torrent's own status counts, the same icons appear in posts
+	// and stylesheets
+	if m := rutrackerTopicStatusRegex.FindStringSubmatch(page); m != nil {
+		switch m[1] {
+		case "consumed":
+			return nil, ErrTopicAbsorbed
+		case "closed":
+			return nil, ErrTopicClosed
+		}
+	}
+
+	title := rutrackerTopicTitleRegex.FindStringSubmatchIndex(page)
+	if title == nil {
+		return nil, fmt.Errorf("topic %s not found on rutracker", id)
+	}
+
+	topic := &models.TopicInfo{
+		Tracker: "rutracker",
+		ID:      id,
+		Title:   cleanText(page[title[2]:title[3]]),
+	}
+
+	// The closest forum link before the title is the topic's section
+	if links := rutrackerForumLinkRegex.FindAllStringSubmatch(page[:title[0]], -1); len(links) > 0 {
+		topic.Section = cleanText(links[len(links)-1][1])
+	}
+	if m := rutrackerTopicSizeRegex.FindStringSubmatch(page); m != nil {
+		topic.Size, _ = utils.ParseSize(cleanText(m[1]))
+	}
+	if m := rutrackerTopicSeedsRegex.FindStringSubmatch(page); m != nil {
+		topic.Seeds, _ = strconv.Atoi(m[1])
+	}
+	if m := rutrackerTopicLeechersRegex.FindStringSubmatch(page); m != nil {
+		topic.Leechers, _ = strconv.Atoi(m[1])
+	}
+	if m := rutrackerRegisteredRegex.FindStringSubmatch(page); m != nil {
+		topic.Registered = cleanText(m[1])
+	}
+	if m := rutrackerPosterRegex.FindStringSubmatch(page); m != nil {
+		topic.PosterURL = resolveURL(topicURL, html.UnescapeString(m[1]))
+	}
+
+	return topic, nil
+}
+
+// rutrackerProfileLinkRegex captures the logged-in user's link in the page header
+var rutrackerProfileLinkRegex = regexp.MustCompile(`(<a[^>]*id="logged-in-username"[^>]*>)`)
+
+// rutrackerProfileFields locates the statistics on a rutracker profile page
+var rutrackerProfileFields = profileFields{
+	Uploaded:   profileMarkers(`id="u_up_total"`),
+	Downloaded: profileMarkers(`id="u_down_total"`),
+	Ratio:      profileMarkers(`id="u_ratio"`, "Ратио"),
+	Bonus:      profileMarkers("Бонусы"),
+}
+
+// Profile implements ProfileScraper
+func (r *rutracker) Profile(c *http.Client) (*models.TrackerProfile, error) {
+	profile, err := fetchForumProfile(c, r, "https://rutracker.org/forum/index.php", rutrackerProfileLinkRegex, rutrackerProfileFields)
+	if err != nil {
+		return nil, err
+	}
+	profile.Username = r.creds.Username
+	profile.MinRatio = r.creds.MinRatio
+	return profile, nil
+}
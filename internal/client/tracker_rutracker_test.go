@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"telegramBot/internal/models"
+)
+
+// readFixture returns a page saved from a tracker, stored as UTF-8 in testdata
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	return string(data)
+}
+
+func TestParseRutrackerSearch(t *testing.T) {
+	want := []models.SearchResult{
+		{
+			Tracker:  "rutracker",
+			ID:       "6543210",
+			Title:    "Ubuntu 24.04 LTS [amd64] 2xDVD & Server",
+			Section:  "Linux для домашнего использования",
+			Size:     6114770944,
+			Seeds:    312,
+			Leechers: 18,
+		},
+		{
+			Tracker:  "rutracker",
+			ID:       "6500001",
+			Title:    "Kubuntu 24.04 (Noble Numbat) [x64]",
+			Section:  `Операционные системы "Linux"`,
+			Size:     4194304000,
+			Seeds:    0,
+			Leechers: 3,
+		},
+	}
+
+	got := parseRutrackerSearch(readFixture(t, "rutracker_search.html"))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRutrackerSearch =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := parseRutrackerSearch(`<table id="tor-tbl"><tbody><tr><td class="row1 tCenter pad_8" colspan="9">Не найдено</td></tr></tbody></table>`); len(got) != 0 {
+		t.Errorf("parseRutrackerSearch of an empty result page = %+v, want none", got)
+	}
+}
+
+func TestParseRutrackerTopic(t *testing.T) {
+	page := readFixture(t, "rutracker_topic.html")
+	topicURL := "https://rutracker.org/forum/viewtopic.php?t=6543210"
+
+	want := &models.TopicInfo{
+		Tracker:    "rutracker",
+		ID:         "6543210",
+		Title:      "Ubuntu 24.04 LTS [amd64] 2xDVD",
+		Section:    "Linux для домашнего использования",
+		Size:       6109590978, // 5.69 GB, the page rounds the size
+		Seeds:      312,
+		Leechers:   18,
+		Registered: "25-Апр-24 18:12",
+		PosterURL:  "https://i123.fastpic.org/big/2024/0425/ab/poster.jpg",
+	}
+	got, err := parseRutrackerTopic(page, topicURL, "6543210")
+	if err != nil {
+		t.Fatalf("parseRutrackerTopic: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRutrackerTopic =\n%+v\nwant\n%+v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		page string
+		want error
+	}{
+		{"closed", strings.Replace(page, `<span class="tor-icon tor-approved">`, `<span class="tor-icon tor-closed">`, 1), ErrTopicClosed},
+		{"absorbed", strings.Replace(page, `<span class="tor-icon tor-approved">`, `<span class="tor-icon tor-consumed">`, 1), ErrTopicAbsorbed},
+		{"status icons elsewhere", strings.Replace(page, "</head>",
+			`<style>.tor-closed, .tor-consumed { color: red; }</style></head>`, 1) +
+			`<div class="post_body"><span class="tor-icon tor-closed">x</span> closed releases are listed below</div>`, nil},
+	}
+	for _, tt := range tests {
+		if _, err := parseRutrackerTopic(tt.page, topicURL, "6543210"); !errors.Is(err, tt.want) {
+			t.Errorf("%s topic: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	missing := `<div class="mrg_16">Тема не найдена</div>`
+	if _, err := parseRutrackerTopic(missing, topicURL, "6543210"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing topic: error = %v, want not found", err)
+	}
+}
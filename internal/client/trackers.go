@@ -1,161 +1,514 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
-	"telegramBot/internal/models"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
+
+	"telegramBot/internal/models"
 )
 
-// TrackerURLs maps tracker names to their download URL patterns
-var TrackerURLs = map[string]string{
-	"rutracker": "https://rutracker.org/forum/dl.php?t=",
-	"kinozal":   "https://dl.kinozal.tv/download.php?id=",
+// numericRegex matches topic IDs made of digits only
+var numericRegex = regexp.MustCompile(`^\d+$`)
+
+// isNumeric reports whether s is a non-empty string of digits
+func isNumeric(s string) bool {
+	return numericRegex.MatchString(s)
 }
 
 // TorrentTrackerClient handles communication with torrent trackers
 type TorrentTrackerClient struct {
-	client      http.Client
-	credentials map[string]models.TrackerCredentials
+	direct   http.Client
+	trackers map[string]Tracker
+	conns    map[string]trackerConn
+	domains  map[string][]string
+	store    *SessionStore
+	logins   map[string]*sync.Mutex // Held while logging in, one per tracker
+
+	mu       sync.Mutex
+	sessions map[string]*trackerSession
 }
 
-// NewTorrentTrackerClient creates a new torrent tracker client
-func NewTorrentTrackerClient(credentials map[string]models.TrackerCredentials) (*TorrentTrackerClient, error) {
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
-	}
+// trackerConn holds a tracker's connection settings, shared by all its
+// sessions so the rate limit survives reconnects
+type trackerConn struct {
+	transport http.RoundTripper
+	timeout   time.Duration
+}
+
+// trackerSession is the HTTP client and cookies of one tracker. Each tracker
+// has its own jar so reconnecting one does not log out the others.
+type trackerSession struct {
+	client   *http.Client
+	jar      *sessionJar
+	loggedIn bool
+}
 
-	client := &http.Client{
-		Jar:     jar,
-		Timeout: 30 * time.Second,
+// NewTorrentTrackerClient creates a new torrent tracker client with every
+// registered tracker, configured with its credentials if present. Sessions
+// are restored from and saved to store when it is not nil.
+func NewTorrentTrackerClient(credentials map[string]models.TrackerCredentials, store *SessionStore) (*TorrentTrackerClient, error) {
+	trackers := make(map[string]Tracker, len(trackerRegistry))
+	conns := make(map[string]trackerConn, len(trackerRegistry))
+	domains := make(map[string][]string, len(trackerRegistry))
+	sessions := make(map[string]*trackerSession, len(trackerRegistry))
+	logins := make(map[string]*sync.Mutex, len(trackerRegistry))
+	for name, factory := range trackerRegistry {
+		creds := credentials[name]
+		tracker := factory(creds)
+		trackers[name] = tracker
+
+		// Links to mirrors and extra domains belong to the tracker too
+		domains[name] = append(domains[name], creds.Domains...)
+		bases := append([]string{creds.SiteURL, creds.DownloadURL}, creds.Mirrors...)
+		for _, mirror := range append(bases, creds.DownloadMirrors...) {
+			if u, err := url.Parse(mirror); err == nil && u.Hostname() != "" {
+				domains[name] = append(domains[name], u.Hostname())
+			}
+		}
+
+		transport, err := newTrackerTransport(creds, tracker)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connection settings for %s: %w", name, err)
+		}
+		conn := trackerConn{transport: transport, timeout: creds.Timeout}
+		if conn.timeout <= 0 {
+			conn.timeout = defaultTrackerTimeout
+		}
+		conns[name] = conn
+
+		session, err := newTrackerSession(conn)
+		if err != nil {
+			return nil, err
+		}
+
+		// Reuse the saved session, it is replaced on the first expired response
+		cookies, err := store.load(name)
+		if err != nil {
+			log.Printf("Ignoring saved %s session: %v", name, err)
+		}
+		session.loggedIn = session.jar.restore(cookies)
+
+		sessions[name] = session
+		logins[name] = &sync.Mutex{}
 	}
 
 	return &TorrentTrackerClient{
-		client:      *client,
-		credentials: credentials,
+		direct:   newDirectClient(false),
+		trackers: trackers,
+		conns:    conns,
+		domains:  domains,
+		store:    store,
+		logins:   logins,
+		sessions: sessions,
 	}, nil
 }
 
-// LoginToTracker authenticates with a torrent tracker
-func (t *TorrentTrackerClient) LoginToTracker(trackerName string) error {
-	creds, exists := t.credentials[trackerName]
+// newTrackerSession creates a logged out session with an empty cookie jar
+func newTrackerSession(conn trackerConn) (*trackerSession, error) {
+	jar, err := newSessionJar()
+	if err != nil {
+		return nil, err
+	}
+
+	return &trackerSession{
+		client: &http.Client{
+			Transport: conn.transport,
+			Jar:       jar,
+			Timeout:   conn.timeout,
+		},
+		jar: jar,
+	}, nil
+}
+
+// Trackers returns all configured trackers sorted by name
+func (t *TorrentTrackerClient) Trackers() []Tracker {
+	var trackers []Tracker
+	for _, name := range RegisteredTrackers() {
+		if tracker, ok := t.trackers[name]; ok {
+			trackers = append(trackers, tracker)
+		}
+	}
+	return trackers
+}
+
+// tracker looks up a tracker by name
+func (t *TorrentTrackerClient) tracker(trackerName string) (Tracker, error) {
+	tracker, exists := t.trackers[trackerName]
 	if !exists {
-		return fmt.Errorf("credentials not found for tracker: %s", trackerName)
+		return nil, fmt.Errorf("unknown tracker: %s", trackerName)
+	}
+	return tracker, nil
+}
+
+// MatchLink finds the tracker a link belongs to
+func (t *TorrentTrackerClient) MatchLink(link string) (Tracker, *url.URL, bool) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, nil, false
 	}
 
-	loginURL := creds.LoginURL
+	for _, tracker := range t.Trackers() {
+		if tracker.MatchURL(u) || hostMatches(u.Hostname(), t.domains[tracker.Name()]) {
+			return tracker, u, true
+		}
+	}
+	return nil, nil, false
+}
+
+// hostMatches reports whether host is one of domains or a subdomain of one
+func hostMatches(host string, domains []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLink extracts the tracker name and topic ID from a tracker link
+func (t *TorrentTrackerClient) ParseLink(link string) (string, string, error) {
+	tracker, u, ok := t.MatchLink(link)
+	if !ok {
+		return "", "", fmt.Errorf("invalid torrent link format")
+	}
+
+	id, err := tracker.ExtractID(u)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tracker.Name(), id, nil
+}
+
+// FindTrackerLink returns the first link in text that belongs to a known tracker
+func (t *TorrentTrackerClient) FindTrackerLink(text string) (string, bool) {
+	for _, link := range FindLinks(text) {
+		if _, _, ok := t.MatchLink(link); ok {
+			return link, true
+		}
+	}
+	return "", false
+}
+
+// session returns the current session of a tracker
+func (t *TorrentTrackerClient) session(trackerName string) *trackerSession {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessions[trackerName]
+}
+
+// saveSession persists the cookies of a tracker's session
+func (t *TorrentTrackerClient) saveSession(trackerName string) {
+	session := t.session(trackerName)
+	if session == nil {
+		return
+	}
+	if err := t.store.save(trackerName, session.jar.snapshot()); err != nil {
+		log.Printf("Failed to save %s session: %v", trackerName, err)
+	}
+}
+
+// LoginToTracker authenticates with a torrent tracker and saves the new session
+func (t *TorrentTrackerClient) LoginToTracker(trackerName string) error {
+	if _, err := t.tracker(trackerName); err != nil {
+		return err
+	}
+
+	login := t.logins[trackerName]
+	login.Lock()
+	defer login.Unlock()
+	return t.login(trackerName)
+}
+
+// login authenticates with a tracker and saves the new session. The caller
+// holds the tracker's login mutex.
+func (t *TorrentTrackerClient) login(trackerName string) error {
+	tracker, err := t.tracker(trackerName)
+	if err != nil {
+		return err
+	}
+
+	session := t.session(trackerName)
+	if err := tracker.Login(session.client); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	session.loggedIn = true
+	t.mu.Unlock()
+
+	t.saveSession(trackerName)
+	return nil
+}
 
-	// Build form data
-	formData := url.Values{}
-	for key, value := range creds.FormData {
-		formData.Set(key, value)
+// CaptchaImage downloads the picture of a captcha challenge using the
+// tracker's session, since the captcha is tied to its cookies
+func (t *TorrentTrackerClient) CaptchaImage(challenge *CaptchaChallenge) ([]byte, error) {
+	session := t.session(challenge.Tracker)
+	if session == nil {
+		return nil, fmt.Errorf("unknown tracker: %s", challenge.Tracker)
 	}
 
-	// Send login request
-	resp, err := t.client.PostForm(loginURL, formData)
+	resp, err := session.client.Get(challenge.ImageURL)
 	if err != nil {
-		return fmt.Errorf("login request failed: %w", err)
+		return nil, fmt.Errorf("captcha request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("login failed with status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("captcha request failed with status code: %d", resp.StatusCode)
 	}
 
+	image, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read captcha: %w", err)
+	}
+	return image, nil
+}
+
+// SolveCaptcha logs in to a tracker with the answer to a captcha challenge
+// and saves the new session
+func (t *TorrentTrackerClient) SolveCaptcha(challenge *CaptchaChallenge, answer string) error {
+	tracker, err := t.tracker(challenge.Tracker)
+	if err != nil {
+		return err
+	}
+
+	solver, ok := tracker.(CaptchaSolver)
+	if !ok {
+		return fmt.Errorf("%s does not support captchas", tracker.DisplayName())
+	}
+
+	login := t.logins[challenge.Tracker]
+	login.Lock()
+	defer login.Unlock()
+
+	session := t.session(challenge.Tracker)
+	if err := solver.LoginWithCaptcha(session.client, challenge, answer); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	session.loggedIn = true
+	t.mu.Unlock()
+
+	t.saveSession(challenge.Tracker)
+	return nil
+}
+
+// relogin logs in to a tracker on behalf of a request that found the session
+// seen logged out, or expired when fresh is set, in which case the session is
+// replaced first. Requests that waited for the login mutex find the session
+// the first one logged in and use it instead of logging in again.
+func (t *TorrentTrackerClient) relogin(trackerName string, seen *trackerSession, fresh bool) error {
+	login := t.logins[trackerName]
+	login.Lock()
+	defer login.Unlock()
+
+	t.mu.Lock()
+	current := t.sessions[trackerName]
+	done := current.loggedIn && (!fresh || current != seen)
+	t.mu.Unlock()
+	if done {
+		return nil
+	}
+
+	if fresh {
+		return t.reconnect(trackerName)
+	}
+	return t.login(trackerName)
+}
+
+// withSession runs fn with a logged in client for a tracker. It logs in only
+// when there is no session yet, and logs in again once if fn reports that
+// the session has expired. Concurrent requests share a single login.
+func (t *TorrentTrackerClient) withSession(trackerName string, fn func(c *http.Client) error) error {
+	session := t.session(trackerName)
+	if session == nil {
+		return fmt.Errorf("unknown tracker: %s", trackerName)
+	}
+
+	t.mu.Lock()
+	loggedIn := session.loggedIn
+	t.mu.Unlock()
+
+	if !loggedIn {
+		if err := t.relogin(trackerName, session, false); err != nil {
+			return err
+		}
+		session = t.session(trackerName)
+	}
+
+	err := fn(session.client)
+	if errors.Is(err, ErrLoggedOut) {
+		// The session expired, start a fresh one and try again
+		if err := t.relogin(trackerName, session, true); err != nil {
+			return fmt.Errorf("reconnection failed: %w", err)
+		}
+		err = fn(t.session(trackerName).client)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The tracker may have refreshed the session cookies
+	t.saveSession(trackerName)
 	return nil
 }
 
 // DownloadTorrent downloads a torrent file from a tracker
 func (t *TorrentTrackerClient) DownloadTorrent(trackerName, id string) ([]byte, error) {
-	// Try to login to tracker
-	if err := t.LoginToTracker(trackerName); err != nil {
-		// If login fails, try to reconnect and login again
-		if err := t.Reconnect(trackerName); err != nil {
-			return nil, fmt.Errorf("reconnection failed: %w", err)
-		}
+	tracker, err := t.tracker(trackerName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get download URL for tracker
-	baseURL, exists := TrackerURLs[trackerName]
-	if !exists {
-		return nil, fmt.Errorf("unknown tracker: %s", trackerName)
+	var body []byte
+	err = t.withSession(trackerName, func(c *http.Client) error {
+		body, err = tracker.Download(c, id)
+		return err
+	})
+	if errors.Is(err, ErrLoggedOut) {
+		return nil, fmt.Errorf("download failed after reconnection: %w", err)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// Download the torrent file
-	downloadURL := baseURL + id
-	resp, err := t.client.Get(downloadURL)
+	return body, nil
+}
+
+// Magnet returns the magnet link for a topic, or an empty string if the
+// tracker does not publish magnet links
+func (t *TorrentTrackerClient) Magnet(trackerName, id string) (string, error) {
+	tracker, err := t.tracker(trackerName)
 	if err != nil {
-		// If download fails, try to reconnect and try again
-		if err := t.Reconnect(trackerName); err != nil {
-			return nil, fmt.Errorf("reconnection failed after download error: %w", err)
-		}
+		return "", err
+	}
 
-		// Try the download again
-		resp, err = t.client.Get(downloadURL)
-		if err != nil {
-			return nil, fmt.Errorf("download request failed after reconnection: %w", err)
-		}
+	provider, ok := tracker.(MagnetProvider)
+	if !ok {
+		return "", nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		// If we get an unauthorized or forbidden status, try to reconnect
-		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-			if err := t.Reconnect(trackerName); err != nil {
-				return nil, fmt.Errorf("reconnection failed after unauthorized error: %w", err)
-			}
+	var magnet string
+	err = t.withSession(trackerName, func(c *http.Client) error {
+		magnet, err = provider.Magnet(c, id)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get magnet link: %w", err)
+	}
+	return magnet, nil
+}
 
-			// Try the download again
-			resp, err = t.client.Get(downloadURL)
-			if err != nil {
-				return nil, fmt.Errorf("download request failed after reconnection: %w", err)
-			}
-			defer resp.Body.Close()
+// Topic scrapes the details of a topic, or returns nil if the tracker can't
+// describe topics. It returns ErrTopicClosed or ErrTopicAbsorbed for topics
+// that can't be downloaded.
+func (t *TorrentTrackerClient) Topic(trackerName, id string) (*models.TopicInfo, error) {
+	tracker, err := t.tracker(trackerName)
+	if err != nil {
+		return nil, err
+	}
 
-			if resp.StatusCode != http.StatusOK {
-				return nil, fmt.Errorf("download failed with status code: %d after reconnection", resp.StatusCode)
-			}
-		} else {
-			return nil, fmt.Errorf("download failed with status code: %d", resp.StatusCode)
-		}
+	scraper, ok := tracker.(TopicScraper)
+	if !ok {
+		return nil, nil
+	}
+
+	var topic *models.TopicInfo
+	err = t.withSession(trackerName, func(c *http.Client) error {
+		topic, err = scraper.Topic(c, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return topic, nil
+}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+// Profile logs in to a tracker if needed and scrapes the account
+// statistics, or returns nil if the tracker has no accounts to describe
+func (t *TorrentTrackerClient) Profile(trackerName string) (*models.TrackerProfile, error) {
+	tracker, err := t.tracker(trackerName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
+	}
+
+	scraper, ok := tracker.(ProfileScraper)
+	if !ok {
+		return nil, nil
 	}
 
-	if len(body) == 0 {
-		return nil, fmt.Errorf("downloaded torrent is empty")
+	var profile *models.TrackerProfile
+	err = t.withSession(trackerName, func(c *http.Client) error {
+		profile, err = scraper.Profile(c)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return profile, nil
+}
 
-	// Validate basic torrent file structure
-	if len(body) < 10 || body[0] != 'd' {
-		return nil, fmt.Errorf("invalid torrent file format")
+// Search searches a tracker that supports it, logging in first if needed
+func (t *TorrentTrackerClient) Search(trackerName, query string) ([]models.SearchResult, error) {
+	tracker, err := t.tracker(trackerName)
+	if err != nil {
+		return nil, err
 	}
 
-	return body, nil
+	searcher, ok := tracker.(Searcher)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support search", tracker.DisplayName())
+	}
+
+	var results []models.SearchResult
+	err = t.withSession(trackerName, func(c *http.Client) error {
+		results, err = searcher.Search(c, query)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	return results, nil
 }
 
-// Reconnect creates a new HTTP client and attempts to login to the specified tracker
+// Reconnect replaces a tracker's session with a fresh one and logs in again.
+// Other trackers keep their sessions.
 func (t *TorrentTrackerClient) Reconnect(trackerName string) error {
-	// Create a new cookie jar
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return fmt.Errorf("failed to create cookie jar: %w", err)
+	if _, err := t.tracker(trackerName); err != nil {
+		return err
 	}
 
-	// Create a new client with the fresh cookies
-	t.client = http.Client{
-		Jar:     jar,
-		Timeout: t.client.Timeout,
+	login := t.logins[trackerName]
+	login.Lock()
+	defer login.Unlock()
+	return t.reconnect(trackerName)
+}
+
+// reconnect replaces a tracker's session and logs in again. The caller holds
+// the tracker's login mutex.
+func (t *TorrentTrackerClient) reconnect(trackerName string) error {
+	session, err := newTrackerSession(t.conns[trackerName])
+	if err != nil {
+		return err
 	}
 
-	// Attempt to login
-	return t.LoginToTracker(trackerName)
+	t.mu.Lock()
+	t.sessions[trackerName] = session
+	t.mu.Unlock()
+
+	return t.login(trackerName)
 }
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"telegramBot/internal/client/proxyfake"
+	"telegramBot/internal/client/qbtfake"
+	"telegramBot/internal/models"
+)
+
+func init() {
+	RegisterTracker("testtracker", func(creds models.TrackerCredentials) Tracker {
+		return &testTracker{}
+	})
+}
+
+// testTracker is a tracker on a made-up host that only the proxy stand-in
+// serves. Its logins are counted and take a while, like a real login form.
+type testTracker struct {
+	logins atomic.Int32
+}
+
+func (t *testTracker) Name() string                            { return "testtracker" }
+func (t *testTracker) DisplayName() string                     { return "Test Tracker" }
+func (t *testTracker) Domain() string                          { return "tracker.test" }
+func (t *testTracker) Hosts() TrackerHosts                     { return TrackerHosts{Site: "http://tracker.test"} }
+func (t *testTracker) MatchURL(u *url.URL) bool                { return u.Hostname() == "tracker.test" }
+func (t *testTracker) ExtractID(u *url.URL) (string, error)    { return u.Query().Get("id"), nil }
+func (t *testTracker) IsLoggedOut(*http.Response, []byte) bool { return false }
+func (t *testTracker) Login(c *http.Client) error {
+	t.logins.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	return nil
+}
+func (t *testTracker) Download(c *http.Client, id string) ([]byte, error) {
+	return downloadTorrentFile(c, t, "http://tracker.test/download?id="+id)
+}
+
+// newProxiedClient starts a proxy stand-in serving torrents and returns a
+// tracker client that reaches testtracker through it
+func newProxiedClient(t *testing.T, creds models.TrackerCredentials) (*TorrentTrackerClient, *proxyfake.Server) {
+	t.Helper()
+
+	torrent := qbtfake.MakeTorrent("proxied", 1<<20)
+	proxy := proxyfake.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-bittorrent")
+		w.Write(torrent)
+	}))
+	t.Cleanup(proxy.Close)
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("proxyuser", "proxypass")
+	creds.ProxyURL = proxyURL.String()
+
+	c, err := NewTorrentTrackerClient(map[string]models.TrackerCredentials{"testtracker": creds}, nil)
+	if err != nil {
+		t.Fatalf("NewTorrentTrackerClient: %v", err)
+	}
+	return c, proxy
+}
+
+func TestTrackerProxyAndUserAgent(t *testing.T) {
+	c, proxy := newProxiedClient(t, models.TrackerCredentials{UserAgent: "TestAgent/1.0"})
+
+	if _, err := c.DownloadTorrent("testtracker", "1"); err != nil {
+		t.Fatalf("DownloadTorrent: %v", err)
+	}
+
+	requests := proxy.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("proxy saw %d requests, want 1", len(requests))
+	}
+	got := requests[0]
+	if got.Host != "tracker.test" || got.Path != "/download" {
+		t.Errorf("proxied request to %s%s, want tracker.test/download", got.Host, got.Path)
+	}
+	if got.UserAgent != "TestAgent/1.0" {
+		t.Errorf("User-Agent = %q, want TestAgent/1.0", got.UserAgent)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("proxyuser:proxypass"))
+	if got.ProxyAuth != wantAuth {
+		t.Errorf("Proxy-Authorization = %q, want %q", got.ProxyAuth, wantAuth)
+	}
+}
+
+func TestTrackerMinInterval(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	c, proxy := newProxiedClient(t, models.TrackerCredentials{MinInterval: interval})
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.DownloadTorrent("testtracker", "1"); err != nil {
+			t.Fatalf("DownloadTorrent: %v", err)
+		}
+	}
+
+	requests := proxy.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("proxy saw %d requests, want 3", len(requests))
+	}
+	for i := 1; i < len(requests); i++ {
+		// Allow for timer granularity
+		if gap := requests[i].Time.Sub(requests[i-1].Time); gap < interval-10*time.Millisecond {
+			t.Errorf("requests %d and %d were %v apart, want at least %v", i-1, i, gap, interval)
+		}
+	}
+}
+
+func TestTrackerMirrorFallback(t *testing.T) {
+	torrent := qbtfake.MakeTorrent("mirrored", 1<<20)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(torrent)
+	}))
+	t.Cleanup(mirror.Close)
+
+	// The first mirror refuses connections
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	c, err := NewTorrentTrackerClient(map[string]models.TrackerCredentials{
+		"testtracker": {Mirrors: []string{downURL, mirror.URL}, Domains: []string{"tracker.example"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewTorrentTrackerClient: %v", err)
+	}
+
+	body, err := c.DownloadTorrent("testtracker", "1")
+	if err != nil {
+		t.Fatalf("DownloadTorrent: %v", err)
+	}
+	if !bytes.Equal(body, torrent) {
+		t.Error("DownloadTorrent returned a different file")
+	}
+
+	for _, link := range []string{"http://tracker.example/download?id=1", mirror.URL + "/download?id=1"} {
+		if name, _, err := c.ParseLink(link); err != nil || name != "testtracker" {
+			t.Errorf("ParseLink(%s) = %q, %v, want testtracker", link, name, err)
+		}
+	}
+}
+
+func TestTrackerConcurrentLogin(t *testing.T) {
+	c, _ := newProxiedClient(t, models.TrackerCredentials{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.DownloadTorrent("testtracker", "1"); err != nil {
+				t.Errorf("DownloadTorrent: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if logins := c.trackers["testtracker"].(*testTracker).logins.Load(); logins != 1 {
+		t.Errorf("logged in %d times, want once", logins)
+	}
+}
+
+func TestTrackerInvalidProxy(t *testing.T) {
+	_, err := NewTorrentTrackerClient(map[string]models.TrackerCredentials{
+		"testtracker": {ProxyURL: "ftp://proxy.test"},
+	}, nil)
+	if err == nil {
+		t.Fatal("NewTorrentTrackerClient accepted an ftp proxy")
+	}
+}
+
+func TestLoginCheckVerify(t *testing.T) {
+	tracker := &rutracker{}
+	tests := []struct {
+		name string
+		page string
+		ok   bool
+		kind error // Kind of the *LoginError when ok is false
+	}{
+		{"logged in page mentions bans", `<a id="logged-in-username">me</a><p>Пользователь заблокирован за флуд</p>`, true, nil},
+		{"banned", `<h4 class="warnColor1 tCenter">Ваш аккаунт заблокирован</h4>`, false, ErrBanned},
+		{"wrong password", `<h4 class="warnColor1">Неверный пароль</h4>`, false, ErrBadCredentials},
+		{"ban in a post only", `<p>Пользователь заблокирован</p>`, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rutrackerLoginCheck.verify(&http.Client{}, tracker, "https://rutracker.org/forum/login.php", tt.page)
+			if tt.ok {
+				if err != nil {
+					t.Errorf("verify() = %v, want success", err)
+				}
+				return
+			}
+
+			var loginErr *LoginError
+			if !errors.As(err, &loginErr) || loginErr.Kind != tt.kind {
+				t.Errorf("verify() = %v, want a login error of kind %v", err, tt.kind)
+			}
+		})
+	}
+}
+
+func TestMirrorTransportRoles(t *testing.T) {
+	var paths []string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/site/login.php" {
+			http.SetCookie(w, &http.Cookie{Name: "uid", Value: "1", Path: "/site/"})
+			http.Redirect(w, r, "/site/index.php", http.StatusFound)
+		}
+	}))
+	t.Cleanup(mirror.Close)
+
+	hosts := TrackerHosts{Site: "http://tracker.test", Download: "http://dl.tracker.test"}
+	transport, err := newMirrorTransport(http.DefaultTransport, "tracker.test", hosts, models.TrackerCredentials{
+		SiteURL:     mirror.URL + "/site",
+		DownloadURL: mirror.URL + "/dl/",
+	})
+	if err != nil {
+		t.Fatalf("newMirrorTransport: %v", err)
+	}
+	c := &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	resp, err := c.Get("http://tracker.test/login.php")
+	if err != nil {
+		t.Fatalf("site request: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Location"); got != "http://tracker.test/index.php" {
+		t.Errorf("Location = %q, want the default site", got)
+	}
+	cookies := resp.Cookies()
+	if len(cookies) != 1 || cookies[0].Domain != "tracker.test" || cookies[0].Path != "/" {
+		t.Errorf("cookies = %v, want uid for domain tracker.test and path /", cookies)
+	}
+
+	// Downloads use their own mirror instead of a subdomain of the site mirror
+	resp, err = c.Get("http://dl.tracker.test/download.php?id=1")
+	if err != nil {
+		t.Fatalf("download request: %v", err)
+	}
+	resp.Body.Close()
+
+	want := []string{"/site/login.php", "/dl/download.php"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("mirror saw %v, want %v", paths, want)
+	}
+}
+
+func TestMirrorTransportDownloadOnSite(t *testing.T) {
+	_, err := newMirrorTransport(http.DefaultTransport, "tracker.test", TrackerHosts{Site: "http://tracker.test"},
+		models.TrackerCredentials{DownloadURL: "https://dl.tracker.example"})
+	if err == nil {
+		t.Fatal("newMirrorTransport accepted a download mirror for a tracker without a download host")
+	}
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"telegramBot/internal/models"
+)
+
+// defaultAPIKeyHeader is the header used for the reverse proxy API key if none is configured
+const defaultAPIKeyHeader = "X-API-Key"
+
+// headerTransport adds authentication and CSRF headers to every request
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+	config  models.QBittorrentCredentials
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests must not be modified, so work on a copy
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[name] = values
+	}
+
+	if t.config.BasicAuthUsername != "" {
+		req.SetBasicAuth(t.config.BasicAuthUsername, t.config.BasicAuthPassword)
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// newQBittorrentTransport builds the HTTP transport for qBittorrent from the
+// TLS, proxy authentication and header settings in config
+func newQBittorrentTransport(config models.QBittorrentCredentials) (http.RoundTripper, error) {
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = tlsConfig
+
+	headers := http.Header{}
+
+	// qBittorrent's CSRF protection compares Referer/Origin with the WebUI host
+	if origin, err := originOf(config.URL); err == nil {
+		headers.Set("Referer", origin)
+		headers.Set("Origin", origin)
+	}
+
+	if config.APIKey != "" {
+		headerName := config.APIKeyHeader
+		if headerName == "" {
+			headerName = defaultAPIKeyHeader
+		}
+		headers.Set(headerName, config.APIKey)
+	}
+
+	for name, value := range config.ExtraHeaders {
+		headers.Set(name, value)
+	}
+
+	return &headerTransport{
+		base:    base,
+		headers: headers,
+		config:  config,
+	}, nil
+}
+
+// newTLSConfig creates the TLS configuration for a custom CA, client
+// certificate or disabled verification
+func newTLSConfig(config models.QBittorrentCredentials) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CACertPath != "" {
+		caCert, err := os.ReadFile(config.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", config.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertPath != "" || config.ClientKeyPath != "" {
+		// The key may be bundled in the certificate file
+		keyPath := config.ClientKeyPath
+		if keyPath == "" {
+			keyPath = config.ClientCertPath
+		}
+
+		cert, err := tls.LoadX509KeyPair(config.ClientCertPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// originOf returns the scheme and host of a URL, e.g. https://example.org:8443
+func originOf(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid URL: %s", rawURL)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// defaultTrackerTimeout is the request timeout for trackers without a configured one
+const defaultTrackerTimeout = 30 * time.Second
+
+// trackerTransport sets the tracker's User-Agent and spaces out requests so
+// the tracker is not hammered
+type trackerTransport struct {
+	base        http.RoundTripper
+	userAgent   string
+	minInterval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // Earliest time the next request may start
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *trackerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	if t.userAgent != "" {
+		// Requests must not be modified, so work on a copy
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// wait blocks until the minimum interval since the previous request has passed
+func (t *trackerTransport) wait(ctx context.Context) error {
+	if t.minInterval <= 0 {
+		return nil
+	}
+
+	// Reserve a slot so concurrent requests queue up one interval apart
+	t.mu.Lock()
+	now := time.Now()
+	start := now
+	if t.next.After(now) {
+		start = t.next
+	}
+	t.next = start.Add(t.minInterval)
+	t.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newTrackerTransport builds the HTTP transport for a tracker from the proxy,
+// user agent, rate limit and mirror settings in creds
+func newTrackerTransport(creds models.TrackerCredentials, tracker Tracker) (http.RoundTripper, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	if creds.ProxyURL != "" {
+		proxyURL, err := url.Parse(creds.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme: %s", proxyURL.Scheme)
+		}
+		base.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	mirrors, err := newMirrorTransport(base, tracker.Domain(), tracker.Hosts(), creds)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trackerTransport{
+		base:        mirrors,
+		userAgent:   creds.UserAgent,
+		minInterval: creds.MinInterval,
+	}, nil
+}
@@ -2,13 +2,29 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"telegramBot/internal/models"
+	"telegramBot/internal/utils"
 )
 
+// trackerEnvPrefixes maps each tracker to the prefix of its environment
+// variables (<PREFIX>USER, <PREFIX>PASSWORD, <PREFIX>LOGIN and <PREFIX>LOGINURL,
+// plus <PREFIX>PROXY, <PREFIX>USERAGENT, <PREFIX>TIMEOUT, <PREFIX>MININTERVAL,
+// <PREFIX>MIRRORS and <PREFIX>DOMAINS).
+// Adding a tracker only needs an entry here next to its implementation in
+// the client package.
+var trackerEnvPrefixes = map[string]string{
+	"rutracker": "RUTRACKER",
+	"kinozal":   "KINOZAL",
+	"nnmclub":   "NNMCLUB",
+	"rutor":     "RUTOR",
+}
+
 // Config holds all application configuration
 type Config struct {
 	TelegramBotToken   string
@@ -16,6 +32,39 @@ type Config struct {
 	TrackerCredentials map[string]models.TrackerCredentials
 	TorrentCategories  map[string]models.TorrentCategory
 	AllowedUsers       []int64
+	AdminUsers         []int64
+	DiskSpace          DiskSpaceConfig
+	Webhook            WebhookConfig
+	TrackerSessions    SessionConfig
+	Watch              WatchConfig
+	Torznab            models.TorznabCredentials
+}
+
+// WatchConfig controls checking watched tracker topics for updates
+type WatchConfig struct {
+	File     string        // JSON file the watched topics are saved to
+	Interval time.Duration // How often the topics are checked
+}
+
+// SessionConfig controls where tracker sessions are saved between restarts
+type SessionConfig struct {
+	Dir string // Directory for session files, empty disables saving
+	Key string // Secret used to encrypt the session files
+}
+
+// WebhookConfig controls the completion webhook server
+type WebhookConfig struct {
+	ListenAddr string // Address to listen on, empty disables the server
+	Secret     string // Shared secret qBittorrent must send
+	OwnersFile string // JSON file the chats that added torrents are saved to
+}
+
+// DiskSpaceConfig controls free space checks before adding torrents
+type DiskSpaceConfig struct {
+	MinFree    int64 // Free space that must remain after adding a torrent
+	Refuse     bool  // Refuse torrents that break MinFree instead of warning
+	AlertBelow int64 // Alert admins when free space drops below this
+	CheckLocal bool  // Also check category save paths on this host
 }
 
 // LoadConfig loads configuration from environment variables
@@ -29,49 +78,82 @@ func LoadConfig() (*Config, error) {
 	if qbtURL == "" {
 		qbtURL = "http://localhost:8080" // Default qBittorrent WebUI URL
 	}
-	var allowedUsersList []int64
+	allowedUsersList, err := parseUserList("ALLOWED_USERS")
+	if err != nil {
+		return nil, err
+	}
 
-	allowUsers := os.Getenv("ALLOWED_USERS")
-	if allowUsers != "" {
-		users := strings.SplitSeq(allowUsers, "|")
-		for user := range users {
-			userID, err := strconv.ParseInt(user, 10, 64)
-			if err != nil {
-				return nil, errors.New("invalid user ID in ALLOWED_USERS")
-			}
-			allowedUsersList = append(allowedUsersList, userID)
-		}
+	// Admins default to every allowed user when not set explicitly
+	adminUsersList, err := parseUserList("ADMIN_USERS")
+	if err != nil {
+		return nil, err
+	}
+	if len(adminUsersList) == 0 {
+		adminUsersList = allowedUsersList
+	}
+
+	extraHeaders, err := parseHeaders("QBITTORRENT_HEADERS")
+	if err != nil {
+		return nil, err
+	}
+
+	diskSpace, err := loadDiskSpaceConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := WebhookConfig{
+		ListenAddr: os.Getenv("WEBHOOK_LISTEN"),
+		Secret:     os.Getenv("WEBHOOK_SECRET"),
+		OwnersFile: os.Getenv("WEBHOOK_OWNERS_FILE"),
+	}
+	if webhook.OwnersFile == "" {
+		webhook.OwnersFile = "torrent_owners.json"
+	}
+	if webhook.ListenAddr != "" && webhook.Secret == "" {
+		return nil, errors.New("WEBHOOK_SECRET environment variable must be set when WEBHOOK_LISTEN is used")
+	}
+
+	sessions := SessionConfig{
+		Dir: os.Getenv("TRACKER_SESSION_DIR"),
+		Key: os.Getenv("TRACKER_SESSION_KEY"),
+	}
+	if sessions.Dir != "" && sessions.Key == "" {
+		return nil, errors.New("TRACKER_SESSION_KEY environment variable must be set when TRACKER_SESSION_DIR is used")
+	}
+
+	trackerCredentials, err := loadTrackerCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	watch, err := loadWatchConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	torznab, err := loadTorznabConfig()
+	if err != nil {
+		return nil, err
 	}
 
 	config := &Config{
 		TelegramBotToken: botToken,
 		QBittorrent: models.QBittorrentCredentials{
-			URL:      qbtURL,
-			Username: os.Getenv("TORRENTUSER"),
-			Password: os.Getenv("TORRENTPASSWORD"),
-		},
-		TrackerCredentials: map[string]models.TrackerCredentials{
-			"rutracker": {
-				LoginURL: "https://rutracker.org/forum/login.php",
-				Username: os.Getenv("RUTRACKERUSER"),
-				Password: os.Getenv("RUTRACKERPASSWORD"),
-				LoginKey: os.Getenv("RUTRACKERLOGIN"),
-				FormData: map[string]string{
-					"login_username": os.Getenv("RUTRACKERUSER"),
-					"login_password": os.Getenv("RUTRACKERPASSWORD"),
-					"login":          os.Getenv("RUTRACKERLOGIN"),
-				},
-			},
-			"kinozal": {
-				LoginURL: "https://kinozal.tv/takelogin.php",
-				Username: os.Getenv("KINOZALUSER"),
-				Password: os.Getenv("KINOZALPASSWORD"),
-				FormData: map[string]string{
-					"username": os.Getenv("KINOZALUSER"),
-					"password": os.Getenv("KINOZALPASSWORD"),
-				},
-			},
+			URL:                qbtURL,
+			Username:           os.Getenv("TORRENTUSER"),
+			Password:           os.Getenv("TORRENTPASSWORD"),
+			CACertPath:         os.Getenv("QBITTORRENT_CA_CERT"),
+			ClientCertPath:     os.Getenv("QBITTORRENT_CLIENT_CERT"),
+			ClientKeyPath:      os.Getenv("QBITTORRENT_CLIENT_KEY"),
+			InsecureSkipVerify: os.Getenv("QBITTORRENT_INSECURE_SKIP_VERIFY") == "true",
+			BasicAuthUsername:  os.Getenv("QBITTORRENT_BASIC_AUTH_USER"),
+			BasicAuthPassword:  os.Getenv("QBITTORRENT_BASIC_AUTH_PASSWORD"),
+			APIKey:             os.Getenv("QBITTORRENT_API_KEY"),
+			APIKeyHeader:       os.Getenv("QBITTORRENT_API_KEY_HEADER"),
+			ExtraHeaders:       extraHeaders,
 		},
+		TrackerCredentials: trackerCredentials,
 		TorrentCategories: map[string]models.TorrentCategory{
 			"Movies.": {
 				Name:     "Movies.",
@@ -109,7 +191,13 @@ func LoadConfig() (*Config, error) {
 				Callback: "COMICS.",
 			},
 		},
-		AllowedUsers: allowedUsersList,
+		AllowedUsers:    allowedUsersList,
+		AdminUsers:      adminUsersList,
+		DiskSpace:       diskSpace,
+		Webhook:         webhook,
+		TrackerSessions: sessions,
+		Watch:           watch,
+		Torznab:         torznab,
 	}
 
 	// Set defaults for save paths if not provided in environment variables
@@ -144,3 +232,181 @@ func LoadConfig() (*Config, error) {
 
 	return config, nil
 }
+
+// loadTrackerCredentials loads the credentials and connection settings of
+// every tracker from environment variables
+func loadTrackerCredentials() (map[string]models.TrackerCredentials, error) {
+	credentials := make(map[string]models.TrackerCredentials, len(trackerEnvPrefixes))
+	for name, prefix := range trackerEnvPrefixes {
+		creds := models.TrackerCredentials{
+			LoginURL:        os.Getenv(prefix + "LOGINURL"),
+			Username:        os.Getenv(prefix + "USER"),
+			Password:        os.Getenv(prefix + "PASSWORD"),
+			LoginKey:        os.Getenv(prefix + "LOGIN"),
+			ProxyURL:        os.Getenv(prefix + "PROXY"),
+			UserAgent:       os.Getenv(prefix + "USERAGENT"),
+			SiteURL:         os.Getenv(prefix + "SITEURL"),
+			DownloadURL:     os.Getenv(prefix + "DOWNLOADURL"),
+			Mirrors:         parseList(prefix + "MIRRORS"),
+			DownloadMirrors: parseList(prefix + "DOWNLOADMIRRORS"),
+			Domains:         parseList(prefix + "DOMAINS"),
+		}
+
+		if value := os.Getenv(prefix + "TIMEOUT"); value != "" {
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("invalid duration in %sTIMEOUT", prefix)
+			}
+			creds.Timeout = timeout
+		}
+		if value := os.Getenv(prefix + "MININTERVAL"); value != "" {
+			interval, err := time.ParseDuration(value)
+			if err != nil || interval < 0 {
+				return nil, fmt.Errorf("invalid duration in %sMININTERVAL", prefix)
+			}
+			creds.MinInterval = interval
+		}
+		if value := os.Getenv(prefix + "MINRATIO"); value != "" {
+			ratio, err := strconv.ParseFloat(value, 64)
+			if err != nil || ratio < 0 {
+				return nil, fmt.Errorf("invalid ratio in %sMINRATIO", prefix)
+			}
+			creds.MinRatio = ratio
+		}
+
+		credentials[name] = creds
+	}
+	return credentials, nil
+}
+
+// parseList parses a "|"-separated list of values from an environment variable
+func parseList(envName string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(envName), "|") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseUserList parses a "|"-separated list of Telegram user IDs from an environment variable
+func parseUserList(envName string) ([]int64, error) {
+	var usersList []int64
+
+	value := os.Getenv(envName)
+	if value == "" {
+		return usersList, nil
+	}
+
+	users := strings.SplitSeq(value, "|")
+	for user := range users {
+		userID, err := strconv.ParseInt(user, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid user ID in " + envName)
+		}
+		usersList = append(usersList, userID)
+	}
+
+	return usersList, nil
+}
+
+// parseHeaders parses a "|"-separated list of "Name: value" headers from an environment variable
+func parseHeaders(envName string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	value := os.Getenv(envName)
+	if value == "" {
+		return headers, nil
+	}
+
+	for header := range strings.SplitSeq(value, "|") {
+		name, headerValue, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, errors.New("invalid header in " + envName + ", expected Name: value")
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(headerValue)
+	}
+
+	return headers, nil
+}
+
+// loadDiskSpaceConfig loads free space check settings from environment variables
+func loadDiskSpaceConfig() (DiskSpaceConfig, error) {
+	var cfg DiskSpaceConfig
+
+	if value := os.Getenv("MIN_FREE_SPACE"); value != "" {
+		size, err := utils.ParseSize(value)
+		if err != nil {
+			return cfg, errors.New("invalid size in MIN_FREE_SPACE")
+		}
+		cfg.MinFree = size
+	}
+
+	switch strings.ToLower(os.Getenv("FREE_SPACE_POLICY")) {
+	case "", "warn":
+		cfg.Refuse = false
+	case "refuse":
+		cfg.Refuse = true
+	default:
+		return cfg, errors.New("FREE_SPACE_POLICY must be either warn or refuse")
+	}
+
+	// Alert threshold defaults to the minimum free space
+	cfg.AlertBelow = cfg.MinFree
+	if value := os.Getenv("LOW_SPACE_ALERT"); value != "" {
+		size, err := utils.ParseSize(value)
+		if err != nil {
+			return cfg, errors.New("invalid size in LOW_SPACE_ALERT")
+		}
+		cfg.AlertBelow = size
+	}
+
+	cfg.CheckLocal = os.Getenv("LOCAL_DISK_CHECK") == "true"
+
+	return cfg, nil
+}
+
+// loadWatchConfig loads the topic watch settings from environment variables
+func loadWatchConfig() (WatchConfig, error) {
+	cfg := WatchConfig{
+		File:     os.Getenv("WATCH_FILE"),
+		Interval: time.Hour,
+	}
+	if cfg.File == "" {
+		cfg.File = "watches.json"
+	}
+
+	if value := os.Getenv("WATCH_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < time.Minute {
+			return cfg, errors.New("WATCH_INTERVAL must be a duration of at least 1m")
+		}
+		cfg.Interval = interval
+	}
+
+	return cfg, nil
+}
+
+// loadTorznabConfig loads the Torznab endpoint from environment variables.
+// Categories are a comma-separated list of Torznab category IDs.
+func loadTorznabConfig() (models.TorznabCredentials, error) {
+	cfg := models.TorznabCredentials{
+		URL:    os.Getenv("TORZNAB_URL"),
+		APIKey: os.Getenv("TORZNAB_API_KEY"),
+	}
+
+	for _, value := range strings.Split(os.Getenv("TORZNAB_CATEGORIES"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		category, err := strconv.Atoi(value)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid category in TORZNAB_CATEGORIES: %s", value)
+		}
+		cfg.Categories = append(cfg.Categories, category)
+	}
+
+	return cfg, nil
+}
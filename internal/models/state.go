@@ -0,0 +1,112 @@
+package models
+
+// TorrentState is a normalized torrent state that hides the naming
+// differences between qBittorrent 4.x and 5.x
+type TorrentState int
+
+const (
+	StateUnknown TorrentState = iota
+	StateDownloading
+	StateForcedDownloading
+	StateMetadata
+	StateStalledDownload
+	StateQueuedDownload
+	StatePausedDownload
+	StateSeeding
+	StateForcedSeeding
+	StateStalledUpload
+	StateQueuedUpload
+	StatePausedUpload
+	StateChecking
+	StateMoving
+	StateMissingFiles
+	StateError
+)
+
+// stateNames maps raw qBittorrent state strings to normalized states.
+// qBittorrent 5.x renamed pausedDL/pausedUP to stoppedDL/stoppedUP.
+var stateNames = map[string]TorrentState{
+	"downloading":        StateDownloading,
+	"forcedDL":           StateForcedDownloading,
+	"metaDL":             StateMetadata,
+	"forcedMetaDL":       StateMetadata,
+	"stalledDL":          StateStalledDownload,
+	"queuedDL":           StateQueuedDownload,
+	"pausedDL":           StatePausedDownload,
+	"stoppedDL":          StatePausedDownload,
+	"uploading":          StateSeeding,
+	"seeding":            StateSeeding,
+	"forcedUP":           StateForcedSeeding,
+	"stalledUP":          StateStalledUpload,
+	"queuedUP":           StateQueuedUpload,
+	"pausedUP":           StatePausedUpload,
+	"stoppedUP":          StatePausedUpload,
+	"checkingDL":         StateChecking,
+	"checkingUP":         StateChecking,
+	"checkingResumeData": StateChecking,
+	"allocating":         StateChecking,
+	"moving":             StateMoving,
+	"missingFiles":       StateMissingFiles,
+	"error":              StateError,
+}
+
+// ParseTorrentState converts a raw qBittorrent state string to a TorrentState
+func ParseTorrentState(state string) TorrentState {
+	if s, ok := stateNames[state]; ok {
+		return s
+	}
+	return StateUnknown
+}
+
+// String returns a human-readable name for the state
+func (s TorrentState) String() string {
+	switch s {
+	case StateDownloading:
+		return "Downloading"
+	case StateForcedDownloading:
+		return "Downloading (forced)"
+	case StateMetadata:
+		return "Fetching metadata"
+	case StateStalledDownload:
+		return "Stalled"
+	case StateQueuedDownload:
+		return "Queued"
+	case StatePausedDownload:
+		return "Paused"
+	case StateSeeding:
+		return "Seeding"
+	case StateForcedSeeding:
+		return "Seeding (forced)"
+	case StateStalledUpload:
+		return "Seeding (stalled)"
+	case StateQueuedUpload:
+		return "Queued for seeding"
+	case StatePausedUpload:
+		return "Completed"
+	case StateChecking:
+		return "Checking"
+	case StateMoving:
+		return "Moving"
+	case StateMissingFiles:
+		return "Missing files"
+	case StateError:
+		return "Error"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsDownloading reports whether the torrent is actively downloading
+func (s TorrentState) IsDownloading() bool {
+	return s == StateDownloading || s == StateForcedDownloading || s == StateMetadata
+}
+
+// IsSeeding reports whether the torrent is actively seeding
+func (s TorrentState) IsSeeding() bool {
+	return s == StateSeeding || s == StateForcedSeeding || s == StateStalledUpload
+}
+
+// IsPaused reports whether the torrent is paused (stopped in qBittorrent 5.x)
+func (s TorrentState) IsPaused() bool {
+	return s == StatePausedDownload || s == StatePausedUpload
+}
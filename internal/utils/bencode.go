@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxBencodeDepth limits nesting so malformed input can't exhaust the stack
+const maxBencodeDepth = 64
+
+// errBencodeTruncated is returned when the input ends in the middle of a value
+var errBencodeTruncated = errors.New("bencode: unexpected end of data")
+
+// TorrentMeta holds the parts of a .torrent file the bot cares about
+type TorrentMeta struct {
+	Name      string
+	TotalSize int64
+	InfoHash  string
+	Trackers  []string
+}
+
+// DecodeBencode decodes a single bencoded value. Strings are returned as
+// string, integers as int64, lists as []any and dictionaries as map[string]any.
+func DecodeBencode(data []byte) (any, error) {
+	value, end, err := decodeBencodeValue(data, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if end != len(data) {
+		return nil, fmt.Errorf("bencode: trailing data at offset %d", end)
+	}
+	return value, nil
+}
+
+// decodeBencodeValue decodes the value starting at pos and returns it with the offset just past it
+func decodeBencodeValue(data []byte, pos, depth int) (any, int, error) {
+	if depth > maxBencodeDepth {
+		return nil, 0, errors.New("bencode: nesting too deep")
+	}
+	if pos >= len(data) {
+		return nil, 0, errBencodeTruncated
+	}
+
+	switch c := data[pos]; {
+	case c == 'i':
+		end := pos + 1
+		for end < len(data) && data[end] != 'e' {
+			end++
+		}
+		if end >= len(data) {
+			return nil, 0, errBencodeTruncated
+		}
+		n, err := strconv.ParseInt(string(data[pos+1:end]), 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("bencode: invalid integer at offset %d", pos)
+		}
+		return n, end + 1, nil
+
+	case c == 'l':
+		list := []any{}
+		pos++
+		for {
+			if pos >= len(data) {
+				return nil, 0, errBencodeTruncated
+			}
+			if data[pos] == 'e' {
+				return list, pos + 1, nil
+			}
+			item, next, err := decodeBencodeValue(data, pos, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			list = append(list, item)
+			pos = next
+		}
+
+	case c == 'd':
+		dict := map[string]any{}
+		pos++
+		for {
+			if pos >= len(data) {
+				return nil, 0, errBencodeTruncated
+			}
+			if data[pos] == 'e' {
+				return dict, pos + 1, nil
+			}
+			key, next, err := decodeBencodeString(data, pos)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, next, err := decodeBencodeValue(data, next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			dict[key] = value
+			pos = next
+		}
+
+	case c >= '0' && c <= '9':
+		s, next, err := decodeBencodeString(data, pos)
+		return s, next, err
+
+	default:
+		return nil, 0, fmt.Errorf("bencode: unexpected byte %q at offset %d", c, pos)
+	}
+}
+
+// decodeBencodeString decodes a length-prefixed string starting at pos
+func decodeBencodeString(data []byte, pos int) (string, int, error) {
+	colon := pos
+	for colon < len(data) && data[colon] != ':' {
+		if data[colon] < '0' || data[colon] > '9' {
+			return "", 0, fmt.Errorf("bencode: invalid string length at offset %d", pos)
+		}
+		colon++
+	}
+	if colon >= len(data) || colon == pos {
+		return "", 0, errBencodeTruncated
+	}
+
+	length, err := strconv.Atoi(string(data[pos:colon]))
+	if err != nil || length < 0 || length > len(data)-colon-1 {
+		return "", 0, errBencodeTruncated
+	}
+
+	start := colon + 1
+	return string(data[start : start+length]), start + length, nil
+}
+
+// ParseTorrentMeta parses a .torrent file and extracts its name, total size,
+// info hash and announce URLs
+func ParseTorrentMeta(data []byte) (*TorrentMeta, error) {
+	if len(data) == 0 || data[0] != 'd' {
+		return nil, errors.New("invalid torrent file format")
+	}
+
+	// Walk the top-level dictionary by hand to find the raw bytes of the
+	// info dictionary, which the info hash is computed over
+	meta := &TorrentMeta{}
+	var info map[string]any
+	var announce string
+	var announceList []any
+
+	pos := 1
+	for {
+		if pos >= len(data) {
+			return nil, errBencodeTruncated
+		}
+		if data[pos] == 'e' {
+			break
+		}
+
+		key, next, err := decodeBencodeString(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		value, end, err := decodeBencodeValue(data, next, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "info":
+			dict, ok := value.(map[string]any)
+			if !ok {
+				return nil, errors.New("invalid torrent file: info is not a dictionary")
+			}
+			info = dict
+			sum := sha1.Sum(data[next:end])
+			meta.InfoHash = hex.EncodeToString(sum[:])
+		case "announce":
+			announce, _ = value.(string)
+		case "announce-list":
+			announceList, _ = value.([]any)
+		}
+		pos = end
+	}
+
+	if info == nil {
+		return nil, errors.New("invalid torrent file: missing info dictionary")
+	}
+
+	meta.Name, _ = info["name"].(string)
+	if length, ok := info["length"].(int64); ok {
+		meta.TotalSize = length
+	} else if files, ok := info["files"].([]any); ok {
+		for _, f := range files {
+			file, ok := f.(map[string]any)
+			if !ok {
+				continue
+			}
+			if length, ok := file["length"].(int64); ok {
+				meta.TotalSize += length
+			}
+		}
+	}
+
+	// Collect unique trackers, preferring the tiered announce list
+	seen := map[string]bool{}
+	addTracker := func(tracker string) {
+		if tracker != "" && !seen[tracker] {
+			seen[tracker] = true
+			meta.Trackers = append(meta.Trackers, tracker)
+		}
+	}
+	addTracker(announce)
+	for _, tier := range announceList {
+		trackers, _ := tier.([]any)
+		for _, t := range trackers {
+			tracker, _ := t.(string)
+			addTracker(tracker)
+		}
+	}
+
+	return meta, nil
+}
+
+// MagnetLink builds a magnet URI for the torrent
+func (m *TorrentMeta) MagnetLink() string {
+	params := url.Values{}
+	if m.Name != "" {
+		params.Set("dn", m.Name)
+	}
+	for _, tracker := range m.Trackers {
+		params.Add("tr", tracker)
+	}
+
+	link := "magnet:?xt=urn:btih:" + m.InfoHash
+	if len(params) > 0 {
+		link += "&" + params.Encode()
+	}
+	return link
+}
+
+// MagnetInfoHash returns the hash qBittorrent identifies a magnet link's
+// torrent by, as lower-case hex, or an empty string if the link has none.
+// A v1 info hash (urn:btih, hex or base32) is preferred, as qBittorrent
+// lists hybrid torrents by it. A v2-only link (urn:btmh, a SHA-256
+// multihash) is listed by its hash truncated to 20 bytes.
+func MagnetInfoHash(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "magnet" {
+		return ""
+	}
+
+	var v2 string
+	for _, xt := range u.Query()["xt"] {
+		if hash, ok := strings.CutPrefix(xt, "urn:btih:"); ok {
+			switch len(hash) {
+			case 40:
+				if _, err := hex.DecodeString(hash); err == nil {
+					return strings.ToLower(hash)
+				}
+			case 32:
+				if raw, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+					return hex.EncodeToString(raw)
+				}
+			}
+		}
+
+		// 0x12 is SHA-256 and 0x20 its 32-byte length
+		if hash, ok := strings.CutPrefix(xt, "urn:btmh:1220"); ok && len(hash) == 64 && v2 == "" {
+			if _, err := hex.DecodeString(hash); err == nil {
+				v2 = strings.ToLower(hash[:40])
+			}
+		}
+	}
+	return v2
+}
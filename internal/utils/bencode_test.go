@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// singleFileInfo and multiFileInfo are info dictionaries whose SHA-1 was
+// computed with a separate implementation
+const (
+	singleFileInfo = "d6:lengthi1048576e4:name10:ubuntu.iso12:piece lengthi262144e6:pieces20:aaaaaaaaaaaaaaaaaaaae"
+	singleFileHash = "e18c6673b6ae2dd1d4ecdc9fd83936e8d22594ab"
+
+	multiFileInfo = "d5:filesld6:lengthi100e4:pathl5:a.mkveed6:lengthi250e4:pathl4:subs5:b.srteee" +
+		"4:name6:Season12:piece lengthi16384e6:pieces20:bbbbbbbbbbbbbbbbbbbbe"
+	multiFileHash = "be4b7a1eceef7b2cda36fed19791f7f9ae388aea"
+)
+
+func TestParseTorrentMeta(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *TorrentMeta
+		wantErr string
+	}{
+		{
+			name: "single file",
+			data: "d8:announce28:http://tracker.test/announce4:info" + singleFileInfo + "e",
+			want: &TorrentMeta{
+				Name:      "ubuntu.iso",
+				TotalSize: 1048576,
+				InfoHash:  singleFileHash,
+				Trackers:  []string{"http://tracker.test/announce"},
+			},
+		},
+		{
+			name: "multiple files",
+			data: "d8:announce28:http://tracker.test/announce13:announce-listll28:http://tracker.test/announceel24:udp://backup.test:80/annee" +
+				"7:comment4:test4:info" + multiFileInfo + "e",
+			want: &TorrentMeta{
+				Name:      "Season",
+				TotalSize: 350,
+				InfoHash:  multiFileHash,
+				Trackers:  []string{"http://tracker.test/announce", "udp://backup.test:80/ann"},
+			},
+		},
+		{
+			name: "info before announce",
+			data: "d4:info" + singleFileInfo + "8:announce28:http://tracker.test/announcee",
+			want: &TorrentMeta{
+				Name:      "ubuntu.iso",
+				TotalSize: 1048576,
+				InfoHash:  singleFileHash,
+				Trackers:  []string{"http://tracker.test/announce"},
+			},
+		},
+		{
+			name:    "empty",
+			data:    "",
+			wantErr: "invalid torrent file format",
+		},
+		{
+			name:    "html page",
+			data:    "<html><body>Login required</body></html>",
+			wantErr: "invalid torrent file format",
+		},
+		{
+			name:    "truncated info",
+			data:    "d8:announce28:http://tracker.test/announce4:info" + singleFileInfo[:40],
+			wantErr: "unexpected end of data",
+		},
+		{
+			name:    "truncated dictionary",
+			data:    "d4:info" + singleFileInfo,
+			wantErr: "unexpected end of data",
+		},
+		{
+			name:    "string longer than the file",
+			data:    "d8:announce999:http://tracker.teste",
+			wantErr: "unexpected end of data",
+		},
+		{
+			name:    "missing info",
+			data:    "d8:announce28:http://tracker.test/announcee",
+			wantErr: "missing info dictionary",
+		},
+		{
+			name:    "info is a list",
+			data:    "d4:infoli1eee",
+			wantErr: "info is not a dictionary",
+		},
+		{
+			name:    "invalid integer",
+			data:    "d4:infod6:lengthi12x4eee",
+			wantErr: "invalid integer",
+		},
+		{
+			name:    "invalid key",
+			data:    "di1e4:infoe",
+			wantErr: "invalid string length",
+		},
+		{
+			name:    "nested too deep",
+			data:    "d4:info" + strings.Repeat("l", 100) + strings.Repeat("e", 100) + "e",
+			wantErr: "nesting too deep",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTorrentMeta([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTorrentMeta: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTorrentMeta = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMagnetLink(t *testing.T) {
+	meta := &TorrentMeta{Name: "ubuntu.iso", InfoHash: singleFileHash, Trackers: []string{"http://tracker.test/announce"}}
+	want := "magnet:?xt=urn:btih:" + singleFileHash + "&dn=ubuntu.iso&tr=http%3A%2F%2Ftracker.test%2Fannounce"
+	if got := meta.MagnetLink(); got != want {
+		t.Errorf("MagnetLink() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeBencode(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    any
+		wantErr bool
+	}{
+		{"i42e", int64(42), false},
+		{"i-7e", int64(-7), false},
+		{"4:spam", "spam", false},
+		{"0:", "", false},
+		{"le", []any{}, false},
+		{"l4:spami1ee", []any{"spam", int64(1)}, false},
+		{"d3:cow3:moo4:spaml1:aee", map[string]any{"cow": "moo", "spam": []any{"a"}}, false},
+		{"i42", nil, true},
+		{"ie", nil, true},
+		{"5:spam", nil, true},
+		{"l4:spam", nil, true},
+		{"d3:cowe", nil, true},
+		{"i1ei2e", nil, true},
+		{"x", nil, true},
+		{"-1:a", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := DecodeBencode([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("DecodeBencode(%q) error = %v, want error %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DecodeBencode(%q) = %#v, want %#v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func FuzzDecodeBencode(f *testing.F) {
+	f.Add([]byte("d8:announce28:http://tracker.test/announce4:info" + singleFileInfo + "e"))
+	f.Add([]byte("d4:info" + multiFileInfo + "e"))
+	f.Add([]byte("l4:spami-1ee"))
+	f.Add([]byte("i9223372036854775807e"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Neither decoder may panic, whatever the input
+		DecodeBencode(data)
+		if meta, err := ParseTorrentMeta(data); err == nil && len(meta.InfoHash) != 40 {
+			t.Fatalf("ParseTorrentMeta(%q) returned info hash %q", data, meta.InfoHash)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// windows1251High maps bytes 0x80-0xBF of Windows-1251 to Unicode.
+// Bytes 0xC0-0xFF map to U+0410-U+044F and are handled arithmetically.
+var windows1251High = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', utf8.RuneError, '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	'\u00a0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '\u00ad', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+// DecodeWindows1251 converts Windows-1251 (CP1251) text, used by Russian
+// trackers, to a UTF-8 string
+func DecodeWindows1251(data []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(data))
+
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			sb.WriteByte(c)
+		case c < 0xC0:
+			sb.WriteRune(windows1251High[c-0x80])
+		default:
+			sb.WriteRune(rune(c-0xC0) + 'А')
+		}
+	}
+
+	return sb.String()
+}
+
+// EncodeWindows1251 converts a UTF-8 string to Windows-1251. Characters
+// without a Windows-1251 equivalent are replaced with '?'.
+func EncodeWindows1251(s string) []byte {
+	out := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			out = append(out, byte(r))
+		case r >= 'А' && r <= 'я':
+			out = append(out, byte(r-'А')+0xC0)
+		default:
+			out = append(out, encodeWindows1251High(r))
+		}
+	}
+
+	return out
+}
+
+// encodeWindows1251High looks up a rune in the 0x80-0xBF range of Windows-1251
+func encodeWindows1251High(r rune) byte {
+	for i, candidate := range windows1251High {
+		if candidate == r && r != utf8.RuneError {
+			return byte(i + 0x80)
+		}
+	}
+	return '?'
+}
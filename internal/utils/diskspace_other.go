@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package utils
+
+import "errors"
+
+// FreeDiskSpace is not supported on this platform
+func FreeDiskSpace(path string) (int64, error) {
+	return 0, errors.New("local disk space checks are not supported on this platform")
+}
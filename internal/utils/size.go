@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps size suffixes to their multipliers (binary units).
+// Russian suffixes are included for sizes scraped from trackers.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+	{"ТБ", 1 << 40},
+	{"ГБ", 1 << 30},
+	{"МБ", 1 << 20},
+	{"КБ", 1 << 10},
+	{"Б", 1},
+}
+
+// ParseSize parses a human-readable size such as "20GB" or "512 MB" into bytes.
+// A bare number is treated as bytes. Trackers may write the decimal
+// separator as a comma, as in "1,46 ГБ".
+func ParseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			multiplier = unit.multiplier
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			break
+		}
+	}
+
+	// ParseFloat also accepts "Inf" and "NaN", which are not sizes
+	n, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil || n < 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, fmt.Errorf("invalid size: %q", value)
+	}
+
+	size := n * float64(multiplier)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("size is too large: %q", value)
+	}
+	return int64(size), nil
+}
@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"512 B", 512, false},
+		{"20GB", 20 << 30, false},
+		{"512 MB", 512 << 20, false},
+		{"1.5 kb", 1536, false},
+		{" 2 TB ", 2 << 40, false},
+		{"1,5 GB", 3 << 29, false},
+		{"1.46 GB", 1567663063, false},
+		{"700 МБ", 700 << 20, false},
+		{"1,46 ГБ", 1567663063, false},
+		{"3 гб", 3 << 30, false},
+		{"10 КБ", 10 << 10, false},
+		{"1 ТБ", 1 << 40, false},
+		{"100 Б", 100, false},
+		{"", 0, true},
+		{"GB", 0, true},
+		{"-1 GB", 0, true},
+		{"ten GB", 0, true},
+		{"Inf", 0, true},
+		{"+Inf GB", 0, true},
+		{"-Inf", 0, true},
+		{"NaN", 0, true},
+		{"NaN MB", 0, true},
+		{"1e30 TB", 0, true},
+		{"9223372036854775807 KB", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
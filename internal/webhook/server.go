@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SecretHeader is the request header carrying the shared secret
+const SecretHeader = "X-Webhook-Secret"
+
+// hashRegex matches v1 (SHA-1) and v2 (SHA-256) torrent info hashes
+var hashRegex = regexp.MustCompile(`^([0-9a-fA-F]{40}|[0-9a-fA-F]{64})$`)
+
+// CompletionHandler is called with the info hash of a finished torrent
+type CompletionHandler func(hash string)
+
+// Server is a small HTTP server that receives torrent completion notifications
+// from qBittorrent's "Run external program on torrent finished" option
+type Server struct {
+	secret      string
+	onCompleted CompletionHandler
+	server      *http.Server
+}
+
+// NewServer creates a webhook server listening on addr
+func NewServer(addr, secret string, onCompleted CompletionHandler) (*Server, error) {
+	if secret == "" {
+		return nil, errors.New("webhook secret must not be empty")
+	}
+
+	s := &Server{
+		secret:      secret,
+		onCompleted: onCompleted,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/torrent/completed", s.handleCompleted)
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s, nil
+}
+
+// Start listens for webhook requests until the server fails
+func (s *Server) Start() error {
+	log.Printf("Webhook server listening on %s", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("webhook server failed: %w", err)
+	}
+	return nil
+}
+
+// handleCompleted accepts a completion notification for a single torrent
+// hash. Only POST requests are accepted and the secret is only read from its
+// header, so it never ends up in URLs, proxy logs or shell history.
+func (s *Server) handleCompleted(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	secret := r.Header.Get(SecretHeader)
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(s.secret)) != 1 {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	hash := strings.TrimSpace(r.FormValue("hash"))
+	if !hashRegex.MatchString(hash) {
+		http.Error(w, "invalid torrent hash", http.StatusBadRequest)
+		return
+	}
+
+	// Handle the notification in the background so qBittorrent isn't kept waiting
+	go s.onCompleted(strings.ToLower(hash))
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleCompleted(t *testing.T) {
+	const hash = "E18C6673B6AE2DD1D4ECDC9FD83936E8D22594AB"
+	completed := make(chan string, 1)
+	s, err := NewServer(":0", "secret", func(hash string) { completed <- hash })
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	server := httptest.NewServer(s.server.Handler)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		hash   string
+		status int
+	}{
+		{"GET", http.MethodGet, "secret", hash, http.StatusMethodNotAllowed},
+		{"missing secret", http.MethodPost, "", hash, http.StatusForbidden},
+		{"wrong secret", http.MethodPost, "guess", hash, http.StatusForbidden},
+		{"short hash", http.MethodPost, "secret", hash[:39], http.StatusBadRequest},
+		{"not hex", http.MethodPost, "secret", strings.Repeat("z", 40), http.StatusBadRequest},
+		{"valid", http.MethodPost, "secret", hash, http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+"/torrent/completed?hash="+tt.hash, nil)
+			if err != nil {
+				t.Fatalf("NewRequest: %v", err)
+			}
+			if tt.secret != "" {
+				req.Header.Set(SecretHeader, tt.secret)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+
+	select {
+	case got := <-completed:
+		if got != strings.ToLower(hash) {
+			t.Errorf("handler called with %q, want %q", got, strings.ToLower(hash))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler was not called for the valid request")
+	}
+	select {
+	case got := <-completed:
+		t.Errorf("handler called again with %q", got)
+	default:
+	}
+}
+
+func TestNewServerRequiresSecret(t *testing.T) {
+	if _, err := NewServer(":0", "", func(string) {}); err == nil {
+		t.Error("NewServer accepted an empty secret")
+	}
+}